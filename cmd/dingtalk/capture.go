@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	url2 "net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chzealot/gobase/dingtalk"
+)
+
+// capture 调用任意接口并输出脱敏后的原始响应，用于更新 dingtalk/models/testdata 中的 fixture。
+// /v1.0/ 开头的路径调用 api.dingtalk.com，其他路径调用 oapi.dingtalk.com
+func (c *command) capture(args []string) (*result, error) {
+	flags := newFlags("capture")
+	path := flags.String("path", "", "接口路径，可以包含 query 参数")
+	method := flags.String("method", http.MethodPost, "HTTP 方法")
+	data := flags.String("data", "", "JSON 请求体")
+	token := flags.String("token", "", "用户 access_token，默认使用应用 access_token")
+	if err := parseFlags(flags, args); err != nil || !strings.HasPrefix(*path, "/") {
+		return nil, errUsage
+	}
+	if *token == "" {
+		client, err := c.cfg.newClient()
+		if err != nil {
+			return nil, err
+		}
+		if *token, err = client.GetAccessToken(); err != nil {
+			return nil, err
+		}
+	}
+
+	api := strings.HasPrefix(*path, "/v1.0/")
+	baseURL := c.cfg.OAPIBaseURL
+	if baseURL == "" {
+		baseURL = dingtalk.DefaultOAPIBaseURL
+	}
+	if api {
+		baseURL = c.cfg.APIBaseURL
+		if baseURL == "" {
+			baseURL = dingtalk.DefaultAPIBaseURL
+		}
+	}
+	u, err := url2.Parse(strings.TrimSuffix(baseURL, "/") + *path)
+	if err != nil {
+		return nil, err
+	}
+	if !api {
+		query := u.Query()
+		query.Set("access_token", *token)
+		u.RawQuery = query.Encode()
+	}
+	var body io.Reader
+	if *data != "" {
+		body = strings.NewReader(*data)
+	}
+	r, err := http.NewRequestWithContext(c.ctx, *method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if api {
+		r.Header.Set("x-acs-dingtalk-access-token", *token)
+	}
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	respBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	anonymized, err := anonymize(respBytes)
+	if err != nil {
+		return nil, fmt.Errorf("capture %s: status=%d, %w", *path, res.StatusCode, err)
+	}
+	return &result{
+		value:   anonymized,
+		headers: []string{"STATUS", "BODY"},
+		rows:    [][]string{{strconv.Itoa(res.StatusCode), string(anonymized)}},
+	}, nil
+}
+
+// sensitiveKeys 响应中需要脱敏的字段（小写），包含 userid、unionid 的字段同样脱敏
+var sensitiveKeys = map[string]bool{
+	"name": true, "nick": true, "nickname": true, "displayname": true, "title": true,
+	"mobile": true, "telephone": true, "email": true, "org_email": true, "orgemail": true,
+	"avatar": true, "avatarurl": true, "jobnumber": true, "job_number": true, "work_place": true,
+	"remark": true, "address": true, "username": true, "corpid": true, "creatorid": true,
+}
+
+// sensitivePrefix 返回脱敏后的值使用的前缀，userId、userIds 等字段使用同一前缀以保留关联关系，
+// 不需要脱敏时返回空字符串
+func sensitivePrefix(key string) string {
+	key = strings.ToLower(key)
+	switch {
+	case strings.Contains(key, "userid") || strings.Contains(key, "user_id"):
+		return "user"
+	case strings.Contains(key, "unionid") || strings.Contains(key, "union_id"):
+		return "union"
+	case sensitiveKeys[key]:
+		return key
+	}
+	return ""
+}
+
+// anonymize 将敏感字段中的字符串替换为 “字段名-序号”，相同的值使用相同的序号以保留关联关系；
+// 数字、字符串形式的数字、时间等其他字段保持原样，保留接口返回的格式差异
+func anonymize(data []byte) (json.RawMessage, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	a := &anonymizer{values: map[string]string{}, counts: map[string]int{}}
+	return json.Marshal(a.walk("", v))
+}
+
+type anonymizer struct {
+	values map[string]string
+	counts map[string]int
+}
+
+func (a *anonymizer) walk(key string, v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		// 按 key 排序遍历，序号不随 map 的遍历顺序变化
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			value[k] = a.walk(k, value[k])
+		}
+		return value
+	case []interface{}:
+		for i, item := range value {
+			value[i] = a.walk(key, item)
+		}
+		return value
+	case string:
+		prefix := sensitivePrefix(key)
+		if value == "" || prefix == "" {
+			return value
+		}
+		if replaced, ok := a.values[prefix+"\x00"+value]; ok {
+			return replaced
+		}
+		a.counts[prefix]++
+		replaced := prefix + "-" + strconv.Itoa(a.counts[prefix])
+		a.values[prefix+"\x00"+value] = replaced
+		return replaced
+	default:
+		return value
+	}
+}
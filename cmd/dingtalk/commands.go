@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk"
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/chzealot/gobase/dingtalk/robot"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04"
+)
+
+type command struct {
+	ctx context.Context
+	cfg *config
+}
+
+func (c *command) dispatch(name string, args []string) (*result, error) {
+	switch name {
+	case "token":
+		return c.subcommand(args, map[string]func([]string) (*result, error){
+			"get":     c.tokenGet,
+			"inspect": c.tokenInspect,
+		})
+	case "user":
+		return c.user(args)
+	case "calendars":
+		return c.calendars(args)
+	case "events":
+		return c.events(args)
+	case "todo":
+		return c.subcommand(args, map[string]func([]string) (*result, error){
+			"create": c.todoCreate,
+			"list":   c.todoList,
+		})
+	case "robot":
+		return c.subcommand(args, map[string]func([]string) (*result, error){
+			"send": c.robotSend,
+		})
+	case "capture":
+		return c.capture(args)
+	}
+	return nil, errUsage
+}
+
+func (c *command) subcommand(args []string, handlers map[string]func([]string) (*result, error)) (*result, error) {
+	if len(args) == 0 {
+		return nil, errUsage
+	}
+	handler, ok := handlers[args[0]]
+	if !ok {
+		return nil, errUsage
+	}
+	return handler(args[1:])
+}
+
+func newFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return errUsage
+	}
+	return nil
+}
+
+func (c *command) tokenGet(args []string) (*result, error) {
+	client, token, err := c.appToken(args)
+	if err != nil {
+		return nil, err
+	}
+	expireAt := client.AccessTokenExpireAt()
+	return &result{
+		value:   map[string]interface{}{"accessToken": token, "expireAt": expireAt},
+		headers: []string{"ACCESS_TOKEN", "EXPIRE_AT"},
+		rows:    [][]string{{token, formatTime(expireAt)}},
+	}, nil
+}
+
+// tokenInspect 输出脱敏后的 token 和有效期，便于在工单中粘贴
+func (c *command) tokenInspect(args []string) (*result, error) {
+	client, token, err := c.appToken(args)
+	if err != nil {
+		return nil, err
+	}
+	expireAt := client.AccessTokenExpireAt()
+	expiresIn := time.Until(expireAt).Round(time.Second)
+	return &result{
+		value: map[string]interface{}{
+			"clientId":    c.cfg.ClientID,
+			"accessToken": maskToken(token),
+			"expireAt":    expireAt,
+			"expiresIn":   int64(expiresIn.Seconds()),
+		},
+		headers: []string{"CLIENT_ID", "ACCESS_TOKEN", "EXPIRE_AT", "EXPIRES_IN"},
+		rows:    [][]string{{c.cfg.ClientID, maskToken(token), formatTime(expireAt), expiresIn.String()}},
+	}, nil
+}
+
+func (c *command) appToken(args []string) (*dingtalk.Client, string, error) {
+	if err := parseFlags(newFlags("token"), args); err != nil {
+		return nil, "", err
+	}
+	client, err := c.cfg.newClient()
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := client.GetAccessToken()
+	if err != nil {
+		return nil, "", err
+	}
+	return client, token, nil
+}
+
+func (c *command) user(args []string) (*result, error) {
+	flags := newFlags("user")
+	unionId := flags.String("unionid", "", "用户 unionId")
+	userId := flags.String("userid", "", "用户 userId")
+	if err := parseFlags(flags, args); err != nil || (*unionId == "") == (*userId == "") {
+		return nil, errUsage
+	}
+	client, err := c.cfg.newClient()
+	if err != nil {
+		return nil, err
+	}
+	if *unionId != "" {
+		if *userId, err = client.GetUserIDByUnionID(*unionId); err != nil {
+			return nil, err
+		}
+	}
+	user, err := client.GetUserFromTop(*userId)
+	if err != nil {
+		return nil, err
+	}
+	return &result{
+		value:   user,
+		headers: []string{"USERID", "UNIONID", "NAME", "MOBILE", "EMAIL", "TITLE", "ACTIVE"},
+		rows: [][]string{{user.Userid, user.UnionID, user.Name, user.Mobile, user.Email, user.Title,
+			strconv.FormatBool(user.Active)}},
+	}, nil
+}
+
+func (c *command) calendars(args []string) (*result, error) {
+	flags := newFlags("calendars")
+	token := flags.String("token", "", "用户 access_token")
+	unionId := flags.String("unionid", "me", "用户 unionId，默认为 token 对应的用户")
+	if err := parseFlags(flags, args); err != nil || *token == "" {
+		return nil, errUsage
+	}
+	client, err := c.cfg.newClient()
+	if err != nil {
+		return nil, err
+	}
+	calendars, err := client.GetCalendars(*token, *unionId)
+	if err != nil {
+		return nil, err
+	}
+	res := &result{value: calendars, headers: []string{"ID", "TYPE", "SUMMARY", "TIMEZONE", "PRIVILEGE"}}
+	for _, cal := range calendars {
+		res.rows = append(res.rows, []string{cal.ID, cal.Type, cal.Summary, cal.TimeZone, cal.Privilege})
+	}
+	return res, nil
+}
+
+func (c *command) events(args []string) (*result, error) {
+	flags := newFlags("events")
+	token := flags.String("token", "", "用户 access_token")
+	unionId := flags.String("unionid", "me", "用户 unionId，默认为 token 对应的用户")
+	calendarId := flags.String("calendar", dingtalk.PrimaryCalendarID, "日历 ID")
+	today := time.Now().In(models.DefaultLocation).Format(dateLayout)
+	from := flags.String("from", today, "开始日期")
+	to := flags.String("to", "", "结束日期（包含），默认与开始日期相同")
+	if err := parseFlags(flags, args); err != nil || *token == "" {
+		return nil, errUsage
+	}
+	if *to == "" {
+		*to = *from
+	}
+	start, err := time.ParseInLocation(dateLayout, *from, models.DefaultLocation)
+	if err != nil {
+		return nil, err
+	}
+	end, err := time.ParseInLocation(dateLayout, *to, models.DefaultLocation)
+	if err != nil {
+		return nil, err
+	}
+	client, err := c.cfg.newClient()
+	if err != nil {
+		return nil, err
+	}
+	if *unionId == "me" {
+		if *unionId, err = client.GetMyUnionID(*token); err != nil {
+			return nil, err
+		}
+	}
+	timeMin := start.Format(time.RFC3339)
+	timeMax := end.AddDate(0, 0, 1).Add(-time.Second).Format(time.RFC3339)
+	events, err := client.IterateCalendarEvents(*token, *unionId, *calendarId, timeMin, timeMax).All(c.ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &result{value: events, headers: []string{"ID", "START", "END", "SUMMARY", "ORGANIZER", "STATUS"}}
+	for _, event := range events {
+		res.rows = append(res.rows, []string{event.ID, formatEventTime(event.Start), formatEventTime(event.End),
+			event.Summary, event.Organizer.DisplayName, event.Status})
+	}
+	return res, nil
+}
+
+func (c *command) todoCreate(args []string) (*result, error) {
+	flags := newFlags("todo create")
+	unionId := flags.String("unionid", "", "创建人 unionId，同时为执行人")
+	subject := flags.String("subject", "", "待办标题")
+	due := flags.String("due", "", "截止时间，格式为 2006-01-02 15:04 或 2006-01-02，默认为明天")
+	if err := parseFlags(flags, args); err != nil || *unionId == "" || *subject == "" {
+		return nil, errUsage
+	}
+	dueTime := time.Now().AddDate(0, 0, 1)
+	if *due != "" {
+		var err error
+		if dueTime, err = parseDueTime(*due); err != nil {
+			return nil, err
+		}
+	}
+	client, err := c.cfg.newClient()
+	if err != nil {
+		return nil, err
+	}
+	todo, err := client.CreateTodoTask(*unionId, *subject, dueTime)
+	if err != nil {
+		return nil, err
+	}
+	return &result{
+		value:   todo,
+		headers: []string{"ID", "SUBJECT", "DUE"},
+		rows:    [][]string{{todo.ID, todo.Subject, formatTime(todo.DueTime.Time)}},
+	}, nil
+}
+
+func (c *command) todoList(args []string) (*result, error) {
+	flags := newFlags("todo list")
+	unionId := flags.String("unionid", "", "用户 unionId")
+	done := flags.Bool("done", false, "查询已完成的待办")
+	if err := parseFlags(flags, args); err != nil || *unionId == "" {
+		return nil, errUsage
+	}
+	client, err := c.cfg.newClient()
+	if err != nil {
+		return nil, err
+	}
+	todos, err := client.ListTodoTasks(c.ctx, *unionId, *done)
+	if err != nil {
+		return nil, err
+	}
+	res := &result{value: todos, headers: []string{"ID", "SUBJECT", "DUE", "DONE", "SOURCE_ID"}}
+	for _, todo := range todos {
+		res.rows = append(res.rows, []string{todo.TaskID, todo.Subject, formatTime(todo.DueTime.Time),
+			strconv.FormatBool(todo.IsDone), todo.SourceID})
+	}
+	return res, nil
+}
+
+func (c *command) robotSend(args []string) (*result, error) {
+	flags := newFlags("robot send")
+	webhook := flags.String("webhook", c.cfg.RobotWebhook, "自定义机器人 webhook 地址")
+	secret := flags.String("secret", c.cfg.RobotSecret, "加签密钥")
+	text := flags.String("text", "", "文本消息")
+	title := flags.String("title", "", "markdown 消息标题")
+	markdown := flags.String("markdown", "", "markdown 消息内容")
+	if err := parseFlags(flags, args); err != nil || *webhook == "" || (*text == "") == (*markdown == "") {
+		return nil, errUsage
+	}
+	if *markdown != "" && *title == "" {
+		return nil, errors.New("robot send, -title is required for markdown")
+	}
+	hook := robot.NewWebhook(*webhook, *secret)
+	var err error
+	msgType := "text"
+	if *text != "" {
+		err = hook.SendText(c.ctx, *text, nil)
+	} else {
+		msgType = "markdown"
+		err = hook.SendMarkdown(c.ctx, *title, *markdown, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &result{
+		value:   map[string]string{"msgType": msgType, "status": "sent"},
+		headers: []string{"MSGTYPE", "STATUS"},
+		rows:    [][]string{{msgType, "sent"}},
+	}, nil
+}
+
+func parseDueTime(s string) (time.Time, error) {
+	if t, err := time.ParseInLocation(dateTimeLayout, s, models.DefaultLocation); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation(dateLayout, s, models.DefaultLocation); err == nil {
+		return t.Add(18 * time.Hour), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.In(models.DefaultLocation).Format(dateTimeLayout)
+}
+
+func formatEventTime(t models.EventTime) string {
+	if !t.DateTime.IsZero() {
+		return formatTime(t.DateTime.Time)
+	}
+	if !t.Date.IsZero() {
+		return t.Date.String()
+	}
+	return "-"
+}
+
+// maskToken 仅保留 token 首尾各 4 个字符
+func maskToken(token string) string {
+	if len(token) <= 8 {
+		return strings.Repeat("*", len(token))
+	}
+	return token[:4] + strings.Repeat("*", len(token)-8) + token[len(token)-4:]
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/chzealot/gobase/dingtalk"
+)
+
+// config 命令行工具的配置，环境变量优先于配置文件
+type config struct {
+	ClientID     string `json:"clientId"`
+	ClientSecret string `json:"clientSecret"`
+	AgentID      string `json:"agentId"`
+	CorpID       string `json:"corpId"`
+	OAPIBaseURL  string `json:"oapiBaseUrl"`
+	APIBaseURL   string `json:"apiBaseUrl"`
+	RobotWebhook string `json:"robotWebhook"`
+	RobotSecret  string `json:"robotSecret"`
+}
+
+// defaultConfigPath 默认配置文件为 $XDG_CONFIG_HOME/dingtalk/config.json
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "dingtalk", "config.json")
+}
+
+// loadConfig 读取配置文件后使用环境变量覆盖，path 为空时使用默认路径，默认配置文件不存在时忽略
+func loadConfig(path string, getenv func(string) string) (*config, error) {
+	cfg := &config{}
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigPath()
+	}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		switch {
+		case err == nil:
+			if err := json.Unmarshal(data, cfg); err != nil {
+				return nil, fmt.Errorf("parse config %s: %w", path, err)
+			}
+		case explicit || !errors.Is(err, os.ErrNotExist):
+			return nil, fmt.Errorf("read config: %w", err)
+		}
+	}
+	for env, field := range map[string]*string{
+		"DINGTALK_CLIENT_ID":     &cfg.ClientID,
+		"DINGTALK_CLIENT_SECRET": &cfg.ClientSecret,
+		"DINGTALK_AGENT_ID":      &cfg.AgentID,
+		"DINGTALK_CORP_ID":       &cfg.CorpID,
+		"DINGTALK_OAPI_BASE_URL": &cfg.OAPIBaseURL,
+		"DINGTALK_API_BASE_URL":  &cfg.APIBaseURL,
+		"DINGTALK_ROBOT_WEBHOOK": &cfg.RobotWebhook,
+		"DINGTALK_ROBOT_SECRET":  &cfg.RobotSecret,
+	} {
+		if v := getenv(env); v != "" {
+			*field = v
+		}
+	}
+	return cfg, nil
+}
+
+func (cfg *config) newClient() (*dingtalk.Client, error) {
+	if cfg.ClientID == "" || cfg.ClientSecret == "" {
+		return nil, errors.New("missing credentials, set DINGTALK_CLIENT_ID and DINGTALK_CLIENT_SECRET or use -config")
+	}
+	client := dingtalk.NewDingTalkClient(cfg.ClientID, cfg.ClientSecret,
+		dingtalk.WithBaseURL(cfg.OAPIBaseURL, cfg.APIBaseURL))
+	client.AgentID = cfg.AgentID
+	client.CorpID = cfg.CorpID
+	return client, nil
+}
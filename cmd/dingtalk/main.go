@@ -0,0 +1,74 @@
+// dingtalk 是调试钉钉集成的命令行工具，凭证从环境变量或配置文件读取
+//
+//	dingtalk [-config file] [-o table|json] <command> [flags]
+//
+// 支持的命令见 usage
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/chzealot/gobase/logger"
+)
+
+const usage = `usage: dingtalk [-config file] [-o table|json] <command> [flags]
+
+commands:
+  token get                       获取应用 access_token
+  token inspect                   查看应用 access_token 的有效期，token 会脱敏
+  user -unionid X | -userid Y     查询用户
+  calendars -token T [-unionid U] 查询用户的日历，token 为用户 access_token
+  events -token T [-unionid U] [-calendar C] [-from 2006-01-02] [-to 2006-01-02]
+                                  查询日程，默认为当天
+  todo create -unionid U -subject S [-due "2006-01-02 15:04"]
+  todo list -unionid U [-done]
+  robot send [-webhook URL] [-secret S] (-text T | -title T -markdown M)
+  capture -path P [-method POST] [-data JSON] [-token T]
+                                  调用接口并输出脱敏后的原始响应，用于更新测试 fixture
+
+environment:
+  DINGTALK_CLIENT_ID, DINGTALK_CLIENT_SECRET, DINGTALK_AGENT_ID, DINGTALK_CORP_ID,
+  DINGTALK_OAPI_BASE_URL, DINGTALK_API_BASE_URL, DINGTALK_ROBOT_WEBHOOK, DINGTALK_ROBOT_SECRET
+`
+
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	_ = logger.InitWithConfig(logger.Config{AppName: "dingtalk-cli", DebugMode: logger.DebugModeFromEnv})
+	if err := run(context.Background(), os.Args[1:], os.Stdout, os.Getenv); err != nil {
+		if errors.Is(err, errUsage) {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "dingtalk:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout io.Writer, getenv func(string) string) error {
+	flags := flag.NewFlagSet("dingtalk", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	configPath := flags.String("config", "", "配置文件路径，默认为 "+defaultConfigPath())
+	format := flags.String("o", formatTable, "输出格式 table 或 json")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		return errUsage
+	}
+	if *format != formatTable && *format != formatJSON {
+		return fmt.Errorf("unknown output format %q, want table or json", *format)
+	}
+	cfg, err := loadConfig(*configPath, getenv)
+	if err != nil {
+		return err
+	}
+	cmd := &command{ctx: ctx, cfg: cfg}
+	res, err := cmd.dispatch(flags.Arg(0), flags.Args()[1:])
+	if err != nil {
+		return err
+	}
+	return writeResult(stdout, *format, res)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chzealot/gobase/dingtalk/dingtalktest"
+	"github.com/chzealot/gobase/logger"
+)
+
+func writeConfig(t *testing.T, cfg config) string {
+	data, _ := json.Marshal(cfg)
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func newEnv(t *testing.T) (*dingtalktest.Server, string, func(string) string) {
+	_ = logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff})
+	s := dingtalktest.NewServer()
+	t.Cleanup(s.Close)
+	s.Store.AddUser(dingtalktest.User{UserID: "user1", UnionID: "union1", Name: "Alice"})
+	configPath := writeConfig(t, config{ClientID: s.ClientID, ClientSecret: "wrong"})
+	env := map[string]string{
+		"DINGTALK_CLIENT_SECRET": s.ClientSecret,
+		"DINGTALK_OAPI_BASE_URL": s.URL,
+		"DINGTALK_API_BASE_URL":  s.URL,
+	}
+	return s, configPath, func(key string) string { return env[key] }
+}
+
+func runCLI(configPath string, getenv func(string) string, args ...string) (string, error) {
+	out := &bytes.Buffer{}
+	err := run(context.Background(), append([]string{"-config", configPath}, args...), out, getenv)
+	return out.String(), err
+}
+
+func TestCommands(t *testing.T) {
+	s, configPath, getenv := newEnv(t)
+
+	out, err := runCLI(configPath, getenv, "-o", "json", "user", "-unionid", "union1")
+	user := map[string]interface{}{}
+	if err != nil || json.Unmarshal([]byte(out), &user) != nil || user["userid"] != "user1" {
+		t.Fatalf("user: out=%s, err=%v", out, err)
+	}
+
+	out, err = runCLI(configPath, getenv, "token", "inspect")
+	if err != nil || !strings.Contains(out, "app-***en-") || !strings.Contains(out, s.ClientID) {
+		t.Fatalf("token inspect: out=%s, err=%v", out, err)
+	}
+
+	if _, err = runCLI(configPath, getenv, "todo", "create", "-unionid", "union1", "-subject", "review", "-due", "2026-10-20"); err != nil {
+		t.Fatalf("todo create: %v", err)
+	}
+	out, err = runCLI(configPath, getenv, "todo", "list", "-unionid", "union1")
+	if err != nil || !strings.Contains(out, "review") || !strings.Contains(out, "2026-10-20 18:00") {
+		t.Fatalf("todo list: out=%s, err=%v", out, err)
+	}
+
+	token := s.Store.IssueUserToken("union1")
+	out, err = runCLI(configPath, getenv, "-o", "json", "calendars", "-token", token)
+	if err != nil || !strings.Contains(out, `"primary"`) {
+		t.Fatalf("calendars: out=%s, err=%v", out, err)
+	}
+}
+
+func TestCapture(t *testing.T) {
+	_, configPath, getenv := newEnv(t)
+
+	out, err := runCLI(configPath, getenv, "-o", "json", "capture", "-path", "/topapi/v2/user/get", "-data", `{"userid":"user1"}`)
+	if err != nil {
+		t.Fatalf("capture: %v", err)
+	}
+	// 保留原始响应的结构，用户信息被脱敏
+	resp := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(out), &resp); err != nil {
+		t.Fatalf("capture output: %s", out)
+	}
+	user, _ := resp["result"].(map[string]interface{})
+	if resp["errcode"] != float64(0) || user == nil || user["userid"] != "user-1" || user["unionid"] != "union-1" || user["name"] != "name-1" {
+		t.Errorf("capture output = %s", out)
+	}
+	if strings.Contains(out, "Alice") || strings.Contains(out, "union1") {
+		t.Errorf("capture output not anonymized: %s", out)
+	}
+}
+
+func TestAnonymize(t *testing.T) {
+	got, err := anonymize([]byte(`{"userId":"u1","createTime":"1677636000000","count":3,
+		"members":[{"userid":"u2","name":"张三"},{"userid":"u1","name":""}],"userIds":["u1","u2"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 相同的 userId 在不同字段中脱敏为相同的值
+	want := `{"count":3,"createTime":"1677636000000","members":[{"name":"name-1","userid":"user-1"},` +
+		`{"name":"","userid":"user-2"}],"userId":"user-2","userIds":["user-2","user-1"]}`
+	if string(got) != want {
+		t.Errorf("anonymize() = %s\nwant %s", got, want)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	_, configPath, getenv := newEnv(t)
+	for _, args := range [][]string{
+		{},
+		{"unknown"},
+		{"token"},
+		{"user"},
+		{"user", "-unionid", "a", "-userid", "b"},
+		{"todo", "create", "-unionid", "union1"},
+	} {
+		if _, err := runCLI(configPath, getenv, args...); !errors.Is(err, errUsage) {
+			t.Errorf("%v: err=%v, want errUsage", args, err)
+		}
+	}
+	if _, err := runCLI(writeConfig(t, config{}), func(string) string { return "" }, "token", "get"); err == nil || errors.Is(err, errUsage) {
+		t.Errorf("missing credentials: err=%v", err)
+	}
+}
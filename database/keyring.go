@@ -0,0 +1,117 @@
+package database
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownKey 密文使用的密钥不在 KeyRing 中，通常是轮换后过早移除了旧密钥
+var ErrUnknownKey = errors.New("database: unknown encryption key")
+
+// KeyRing AES-GCM 密钥环，使用主密钥加密，按密文中的密钥 ID 解密，
+// 轮换时将新密钥设为主密钥并保留旧密钥，直到所有数据重新加密
+type KeyRing struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// NewKeyRing 创建密钥环，keys 为密钥 ID 到 16、24 或 32 字节密钥的映射，primary 为加密使用的密钥 ID
+func NewKeyRing(primary string, keys map[string][]byte) (*KeyRing, error) {
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("NewKeyRing, primary key %q not found", primary)
+	}
+	ring := &KeyRing{primary: primary, aeads: map[string]cipher.AEAD{}}
+	for id, key := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("NewKeyRing, invalid key id %q", id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("NewKeyRing, key %q", id))
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("NewKeyRing, key %q", id))
+		}
+		ring.aeads[id] = aead
+	}
+	return ring, nil
+}
+
+// ParseKeyRing 解析 “id1:base64key1,id2:base64key2” 格式的密钥配置，第一个为主密钥
+func ParseKeyRing(spec string) (*KeyRing, error) {
+	primary := ""
+	keys := map[string][]byte{}
+	for _, item := range strings.Split(spec, ",") {
+		id, encoded, ok := strings.Cut(strings.TrimSpace(item), ":")
+		if !ok {
+			return nil, fmt.Errorf("ParseKeyRing, invalid key %q, want id:base64", item)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.Wrap(err, fmt.Sprintf("ParseKeyRing, key %q", id))
+		}
+		if primary == "" {
+			primary = id
+		}
+		keys[id] = key
+	}
+	return NewKeyRing(primary, keys)
+}
+
+// KeyRingFromEnv 从 env.TOKEN_ENCRYPTION_KEYS 读取密钥配置，格式见 ParseKeyRing
+func KeyRingFromEnv() (*KeyRing, error) {
+	spec, ok := os.LookupEnv("TOKEN_ENCRYPTION_KEYS")
+	if !ok || spec == "" {
+		return nil, fmt.Errorf("KeyRingFromEnv, env.TOKEN_ENCRYPTION_KEYS is empty")
+	}
+	return ParseKeyRing(spec)
+}
+
+// PrimaryKeyID 返回加密使用的密钥 ID
+func (k *KeyRing) PrimaryKeyID() string {
+	return k.primary
+}
+
+// Encrypt 使用主密钥加密，additionalData 参与认证但不加密，解密时必须一致，
+// 返回 “密钥ID:base64(nonce+密文)”
+func (k *KeyRing) Encrypt(plaintext, additionalData []byte) (string, error) {
+	aead := k.aeads[k.primary]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, plaintext, additionalData)
+	return k.primary + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密 Encrypt 的结果
+func (k *KeyRing) Decrypt(ciphertext string, additionalData []byte) ([]byte, error) {
+	id, encoded, ok := strings.Cut(ciphertext, ":")
+	if !ok {
+		return nil, errors.New("KeyRing.Decrypt, invalid ciphertext")
+	}
+	aead, ok := k.aeads[id]
+	if !ok {
+		return nil, errors.Wrap(ErrUnknownKey, id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "KeyRing.Decrypt")
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("KeyRing.Decrypt, ciphertext too short")
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additionalData)
+	if err != nil {
+		return nil, errors.Wrap(err, "KeyRing.Decrypt")
+	}
+	return plaintext, nil
+}
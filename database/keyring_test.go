@@ -0,0 +1,49 @@
+package database
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyRing(t *testing.T) {
+	k1 := bytes.Repeat([]byte{1}, 32)
+	k2 := bytes.Repeat([]byte{2}, 32)
+	old, err := ParseKeyRing("k1:" + base64.StdEncoding.EncodeToString(k1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aad := tokenAAD("union1", "corp1")
+	ciphertext, err := old.Encrypt([]byte("secret-token"), aad)
+	if err != nil || !strings.HasPrefix(ciphertext, "k1:") || strings.Contains(ciphertext, "secret-token") {
+		t.Fatalf("ciphertext=%s, err=%v", ciphertext, err)
+	}
+	if _, err := old.Decrypt(ciphertext, tokenAAD("union2", "corp1")); err == nil {
+		t.Errorf("decrypt with other aad should fail")
+	}
+
+	// 轮换后新数据使用 k2，旧数据仍可以用 k1 解密
+	rotated, err := NewKeyRing("k2", map[string][]byte{"k1": k1, "k2": k2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := rotated.Decrypt(ciphertext, aad)
+	if err != nil || string(plaintext) != "secret-token" {
+		t.Fatalf("plaintext=%s, err=%v", plaintext, err)
+	}
+	reencrypted, _ := rotated.Encrypt(plaintext, aad)
+	if keyID(reencrypted) != "k2" {
+		t.Errorf("key id = %s", keyID(reencrypted))
+	}
+	if _, err := old.Decrypt(reencrypted, aad); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("err=%v, want ErrUnknownKey", err)
+	}
+
+	for _, spec := range []string{"", "k1", "k1:!!!", "k1:" + base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := ParseKeyRing(spec); err == nil {
+			t.Errorf("ParseKeyRing(%q) should fail", spec)
+		}
+	}
+}
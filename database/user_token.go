@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUserTokenNotFound 用户 token 不存在或已撤销
+var ErrUserTokenNotFound = errors.New("database: user token not found")
+
+// UserToken 加密保存的钉钉用户 access_token 和 refresh_token，每个 unionId、corpId 一条记录
+type UserToken struct {
+	ID           uint64    `gorm:"primaryKey"`
+	UnionID      string    `gorm:"size:64;not null;uniqueIndex:idx_user_token_union_corp"`
+	CorpID       string    `gorm:"size:64;not null;uniqueIndex:idx_user_token_union_corp"`
+	KeyID        string    `gorm:"size:32;not null;index"`
+	AccessToken  string    `gorm:"type:text;not null"`
+	RefreshToken string    `gorm:"type:text;not null"`
+	ExpireAt     time.Time `gorm:"not null;index"`
+	RevokedAt    *time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (UserToken) TableName() string {
+	return "dingtalk_user_tokens"
+}
+
+// UserTokenRepository 保存用户 token，token 使用 KeyRing 加密，unionId 和 corpId 作为附加认证数据，
+// 密文不能被复制到其他用户的记录中使用
+type UserTokenRepository struct {
+	db   *gorm.DB
+	keys *KeyRing
+}
+
+// UserTokens 返回用户 token 仓库，需要先调用 InitDbFromEnv
+func (d *Database) UserTokens(keys *KeyRing) *UserTokenRepository {
+	return &UserTokenRepository{db: d.DB, keys: keys}
+}
+
+// AutoMigrate 创建或更新 dingtalk_user_tokens 表
+func (r *UserTokenRepository) AutoMigrate() error {
+	return r.db.AutoMigrate(&UserToken{})
+}
+
+// Save 保存 unionId 的 token，corpId 取自 token.CorpId，已存在时覆盖并取消撤销状态
+func (r *UserTokenRepository) Save(ctx context.Context, unionId string, token *models.UserAccessTokenResponse) error {
+	if unionId == "" || token == nil || token.AccessToken == "" {
+		return errors.New("UserTokenRepository.Save, unionId and accessToken are required")
+	}
+	expireAt := time.Unix(token.ExpireTime, 0)
+	if token.ExpireTime == 0 {
+		expireAt = time.Now().Add(time.Duration(token.ExpireIn) * time.Second)
+	}
+	row := &UserToken{UnionID: unionId, CorpID: token.CorpId, ExpireAt: expireAt}
+	if err := r.encrypt(row, token.AccessToken, token.RefreshToken); err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "union_id"}, {Name: "corp_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"key_id":        row.KeyID,
+			"access_token":  row.AccessToken,
+			"refresh_token": row.RefreshToken,
+			"expire_at":     row.ExpireAt,
+			"revoked_at":    nil,
+			"updated_at":    time.Now(),
+		}),
+	}).Create(row).Error
+}
+
+// Get 查询 unionId 在 corpId 下的 token，access_token 过期时仍然返回，调用方可以使用 RefreshToken 刷新，
+// 不存在或已撤销时返回 ErrUserTokenNotFound
+func (r *UserTokenRepository) Get(ctx context.Context, unionId, corpId string) (*models.UserAccessTokenResponse, error) {
+	row := &UserToken{}
+	err := r.db.WithContext(ctx).Where("union_id = ? AND corp_id = ? AND revoked_at IS NULL", unionId, corpId).Take(row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUserTokenNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	accessToken, refreshToken, err := r.decrypt(row)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserAccessTokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpireIn:     int64(time.Until(row.ExpireAt).Seconds()),
+		CorpId:       row.CorpID,
+		ExpireTime:   row.ExpireAt.Unix(),
+	}, nil
+}
+
+// Revoke 撤销 token 并清除密文，如用户取消授权或离职
+func (r *UserTokenRepository) Revoke(ctx context.Context, unionId, corpId string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&UserToken{}).
+		Where("union_id = ? AND corp_id = ?", unionId, corpId).
+		Updates(map[string]interface{}{"access_token": "", "refresh_token": "", "revoked_at": &now}).Error
+}
+
+// DeleteExpired 删除 access_token 在 before 之前过期的记录和已撤销的记录，返回删除的数量。
+// refresh_token 的有效期比 access_token 长，before 通常为当前时间减去 refresh_token 的有效期
+func (r *UserTokenRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expire_at < ? OR revoked_at IS NOT NULL", before).Delete(&UserToken{})
+	return result.RowsAffected, result.Error
+}
+
+// Rotate 使用主密钥重新加密最多 batchSize 条使用旧密钥的记录，返回处理的数量，
+// 返回 0 后可以从 KeyRing 中移除旧密钥
+func (r *UserTokenRepository) Rotate(ctx context.Context, batchSize int) (int, error) {
+	var rows []*UserToken
+	err := r.db.WithContext(ctx).Where("key_id <> ? AND revoked_at IS NULL", r.keys.PrimaryKeyID()).
+		Limit(batchSize).Find(&rows).Error
+	if err != nil {
+		return 0, err
+	}
+	for i, row := range rows {
+		accessToken, refreshToken, err := r.decrypt(row)
+		if err != nil {
+			return i, err
+		}
+		oldKeyID := row.KeyID
+		if err := r.encrypt(row, accessToken, refreshToken); err != nil {
+			return i, err
+		}
+		// 按旧密钥 ID 更新，避免覆盖并发 Save 写入的新 token
+		err = r.db.WithContext(ctx).Model(&UserToken{}).Where("id = ? AND key_id = ?", row.ID, oldKeyID).
+			Updates(map[string]interface{}{
+				"key_id":        row.KeyID,
+				"access_token":  row.AccessToken,
+				"refresh_token": row.RefreshToken,
+			}).Error
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(rows), nil
+}
+
+func (r *UserTokenRepository) encrypt(row *UserToken, accessToken, refreshToken string) error {
+	aad := tokenAAD(row.UnionID, row.CorpID)
+	var err error
+	if row.AccessToken, err = r.keys.Encrypt([]byte(accessToken), aad); err != nil {
+		return err
+	}
+	if row.RefreshToken, err = r.keys.Encrypt([]byte(refreshToken), aad); err != nil {
+		return err
+	}
+	row.KeyID = r.keys.PrimaryKeyID()
+	return nil
+}
+
+func (r *UserTokenRepository) decrypt(row *UserToken) (string, string, error) {
+	aad := tokenAAD(row.UnionID, row.CorpID)
+	accessToken, err := r.keys.Decrypt(row.AccessToken, aad)
+	if err != nil {
+		return "", "", errors.Wrap(err, "decrypt access token of "+row.UnionID)
+	}
+	refreshToken, err := r.keys.Decrypt(row.RefreshToken, aad)
+	if err != nil {
+		return "", "", errors.Wrap(err, "decrypt refresh token of "+row.UnionID)
+	}
+	return string(accessToken), string(refreshToken), nil
+}
+
+func tokenAAD(unionId, corpId string) []byte {
+	return []byte("dingtalk-user-token:" + unionId + ":" + corpId)
+}
@@ -0,0 +1,281 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeConn 记录执行的 SQL，查询结果由 rows 按顺序返回，用于在没有 MySQL 的环境下测试仓库生成的语句
+type fakeConn struct {
+	mutex    sync.Mutex
+	stmts    []fakeStmt
+	rows     []*fakeRows
+	affected int64
+}
+
+type fakeStmt struct {
+	query string
+	args  []driver.Value
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakeConn: prepare is not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *fakeConn) Commit() error             { return nil }
+func (c *fakeConn) Rollback() error           { return nil }
+
+func (c *fakeConn) record(query string, named []driver.NamedValue) {
+	args := make([]driver.Value, len(named))
+	for i, v := range named {
+		args[i] = v.Value
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.stmts = append(c.stmts, fakeStmt{query: query, args: args})
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.record(query, args)
+	return fakeResult(c.affected), nil
+}
+
+type fakeResult int64
+
+func (r fakeResult) LastInsertId() (int64, error) { return 1, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.record(query, args)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.rows) == 0 {
+		return &fakeRows{}, nil
+	}
+	rows := c.rows[0]
+	c.rows = c.rows[1:]
+	return rows, nil
+}
+
+// take 返回并清空已记录的 SQL
+func (c *fakeConn) take() []fakeStmt {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	stmts := c.stmts
+	c.stmts = nil
+	return stmts
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+type fakeConnector struct{ conn *fakeConn }
+
+func (f fakeConnector) Connect(context.Context) (driver.Conn, error) { return f.conn, nil }
+func (f fakeConnector) Driver() driver.Driver                        { return nil }
+
+var userTokenColumns = []string{"id", "union_id", "corp_id", "key_id", "access_token", "refresh_token", "expire_at", "revoked_at"}
+
+func newTestRepository(t *testing.T, keys *KeyRing) (*UserTokenRepository, *fakeConn) {
+	conn := &fakeConn{affected: 1}
+	sqlDB := sql.OpenDB(fakeConnector{conn: conn})
+	t.Cleanup(func() { _ = sqlDB.Close() })
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}),
+		&gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Database{DB: db}
+	return d.UserTokens(keys), conn
+}
+
+func testKeyRing(t *testing.T, primary string) *KeyRing {
+	keys, err := ParseKeyRing("k1:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32)) +
+		",k2:" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{2}, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys.primary = primary
+	return keys
+}
+
+func TestUserTokenSaveAndGet(t *testing.T) {
+	repo, conn := newTestRepository(t, testKeyRing(t, "k1"))
+	ctx := context.Background()
+
+	if err := repo.Save(ctx, "union1", &models.UserAccessTokenResponse{}); err == nil {
+		t.Error("Save() without accessToken error = nil")
+	}
+	expireTime := time.Now().Add(time.Hour).Unix()
+	token := &models.UserAccessTokenResponse{AccessToken: "at-1", RefreshToken: "rt-1", CorpId: "corp1", ExpireTime: expireTime}
+	if err := repo.Save(ctx, "union1", token); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	stmts := conn.take()
+	if len(stmts) != 1 {
+		t.Fatalf("Save() statements = %+v", stmts)
+	}
+	// 按 unionId、corpId 覆盖已有记录，并取消撤销状态
+	insert := stmts[0]
+	if !strings.HasPrefix(insert.query, "INSERT INTO `dingtalk_user_tokens`") || !strings.HasSuffix(insert.query,
+		"ON DUPLICATE KEY UPDATE `access_token`=?,`expire_at`=?,`key_id`=?,`refresh_token`=?,`revoked_at`=?,`updated_at`=?") {
+		t.Errorf("Save() query = %s", insert.query)
+	}
+	args := insert.args
+	accessToken, _ := args[3].(string)
+	refreshToken, _ := args[4].(string)
+	if args[0] != "union1" || args[1] != "corp1" || args[2] != "k1" ||
+		keyID(accessToken) != "k1" || keyID(refreshToken) != "k1" || strings.Contains(accessToken, "at-1") {
+		t.Fatalf("Save() args = %v", args)
+	}
+	if args[9] != accessToken || args[11] != "k1" || args[12] != refreshToken || args[13] != nil {
+		t.Errorf("Save() update args = %v", args[9:])
+	}
+
+	// 查询时解密
+	expireAt := time.Unix(expireTime, 0)
+	conn.rows = []*fakeRows{{columns: userTokenColumns, values: [][]driver.Value{
+		{int64(1), "union1", "corp1", "k1", accessToken, refreshToken, expireAt, nil},
+	}}}
+	got, err := repo.Get(ctx, "union1", "corp1")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if got.AccessToken != "at-1" || got.RefreshToken != "rt-1" || got.CorpId != "corp1" || got.ExpireTime != expireTime || got.ExpireIn <= 0 {
+		t.Errorf("Get() = %+v", got)
+	}
+	if query := conn.take()[0].query; !strings.Contains(query, "revoked_at IS NULL") {
+		t.Errorf("Get() query = %s", query)
+	}
+
+	// 已撤销或不存在时没有结果
+	if _, err := repo.Get(ctx, "union1", "corp1"); !errors.Is(err, ErrUserTokenNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrUserTokenNotFound", err)
+	}
+	// 密文被复制到其他用户的记录时无法解密
+	conn.rows = []*fakeRows{{columns: userTokenColumns, values: [][]driver.Value{
+		{int64(2), "union2", "corp1", "k1", accessToken, refreshToken, expireAt, nil},
+	}}}
+	if _, err := repo.Get(ctx, "union2", "corp1"); err == nil {
+		t.Error("Get() with copied ciphertext error = nil")
+	}
+}
+
+func TestUserTokenRevokeAndDeleteExpired(t *testing.T) {
+	repo, conn := newTestRepository(t, testKeyRing(t, "k1"))
+	ctx := context.Background()
+
+	if err := repo.Revoke(ctx, "union1", "corp1"); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+	revoke := conn.take()[0]
+	if !strings.HasPrefix(revoke.query, "UPDATE `dingtalk_user_tokens` SET `access_token`=?,`refresh_token`=?,`revoked_at`=?") ||
+		!strings.Contains(revoke.query, "WHERE union_id = ? AND corp_id = ?") {
+		t.Errorf("Revoke() query = %s", revoke.query)
+	}
+	if revoke.args[0] != "" || revoke.args[1] != "" || revoke.args[2] == nil {
+		t.Errorf("Revoke() args = %v", revoke.args)
+	}
+
+	conn.affected = 3
+	before := time.Now().Add(-30 * 24 * time.Hour)
+	n, err := repo.DeleteExpired(ctx, before)
+	if err != nil || n != 3 {
+		t.Fatalf("DeleteExpired() = %d, %v", n, err)
+	}
+	del := conn.take()[0]
+	if !strings.HasPrefix(del.query, "DELETE FROM `dingtalk_user_tokens` WHERE") ||
+		!strings.Contains(del.query, "expire_at < ? OR revoked_at IS NOT NULL") {
+		t.Errorf("DeleteExpired() query = %s", del.query)
+	}
+	if at, ok := del.args[0].(time.Time); !ok || !at.Equal(before) {
+		t.Errorf("DeleteExpired() args = %v", del.args)
+	}
+}
+
+func TestUserTokenRotate(t *testing.T) {
+	old := testKeyRing(t, "k1")
+	expireAt := time.Now().Add(time.Hour)
+	var values [][]driver.Value
+	for i, unionId := range []string{"union1", "union2"} {
+		aad := tokenAAD(unionId, "corp1")
+		accessToken, _ := old.Encrypt([]byte("at-"+unionId), aad)
+		refreshToken, _ := old.Encrypt([]byte("rt-"+unionId), aad)
+		values = append(values, []driver.Value{int64(i + 1), unionId, "corp1", "k1", accessToken, refreshToken, expireAt, nil})
+	}
+
+	keys := testKeyRing(t, "k2")
+	repo, conn := newTestRepository(t, keys)
+	conn.rows = []*fakeRows{{columns: userTokenColumns, values: values}}
+	n, err := repo.Rotate(context.Background(), 10)
+	if err != nil || n != 2 {
+		t.Fatalf("Rotate() = %d, %v", n, err)
+	}
+	stmts := conn.take()
+	if len(stmts) != 3 {
+		t.Fatalf("Rotate() statements = %+v", stmts)
+	}
+	query := stmts[0]
+	if !strings.Contains(query.query, "key_id <> ? AND revoked_at IS NULL") || !strings.HasSuffix(query.query, "LIMIT 10") ||
+		query.args[0] != "k2" {
+		t.Errorf("Rotate() query = %s %v", query.query, query.args)
+	}
+	for i, update := range stmts[1:] {
+		// 按 id 和旧密钥 ID 更新，并发 Save 写入的新 token 不会被覆盖
+		if !strings.Contains(update.query, "WHERE id = ? AND key_id = ?") {
+			t.Errorf("Rotate() update = %s", update.query)
+		}
+		args := update.args
+		if args[1] != "k2" || args[4] != int64(i+1) || args[5] != "k1" {
+			t.Errorf("Rotate() update args = %v", args)
+		}
+		unionId := values[i][1].(string)
+		accessToken, _ := args[0].(string)
+		plaintext, err := keys.Decrypt(accessToken, tokenAAD(unionId, "corp1"))
+		if err != nil || string(plaintext) != "at-"+unionId || keyID(accessToken) != "k2" {
+			t.Errorf("Rotate() access token = %q, %v", plaintext, err)
+		}
+	}
+
+	// 无法解密时返回已处理的数量
+	conn.rows = []*fakeRows{{columns: userTokenColumns, values: [][]driver.Value{
+		{int64(3), "union3", "corp1", "k0", "k0:AAAA", "k0:AAAA", expireAt, nil},
+	}}}
+	if n, err := repo.Rotate(context.Background(), 10); err == nil || n != 0 {
+		t.Errorf("Rotate() with unknown key = %d, %v", n, err)
+	}
+}
+
+// keyID 返回密文使用的密钥 ID
+func keyID(ciphertext string) string {
+	id, _, _ := strings.Cut(ciphertext, ":")
+	return id
+}
@@ -0,0 +1,177 @@
+package dingtalk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+const (
+	// 打卡详情接口每次最多查询 50 个用户、7 天
+	punchRecordMaxUsers = 50
+	punchRecordMaxDays  = 7
+	// 请假状态接口每次最多查询 100 个用户
+	leaveStatusMaxUsers = 100
+	leaveStatusPageSize = 20
+	leaveQuotaMaxUsers  = 50
+	leaveQuotaPageSize  = 50
+	groupPageSize       = 10
+)
+
+const attendanceTimeLayout = "2006-01-02 15:04:05"
+
+// IteratePunchRecords 遍历 userIds 在 [from, to] 内的打卡详情，内部处理 50 人和 7 天的限制，
+// 每次只在内存中保留一个批次（50 人 * 7 天）
+func (c *Client) IteratePunchRecords(userIds []string, from, to time.Time) *Pager[models.PunchRecord] {
+	return NewPager(func(ctx context.Context, cursor string) (*Page[models.PunchRecord], error) {
+		// 游标格式为 "用户偏移:时间窗口起点"
+		userOffset, windowStart := 0, from
+		if cursor != "" {
+			var startNano int64
+			if _, err := fmt.Sscanf(cursor, "%d:%d", &userOffset, &startNano); err != nil {
+				return nil, fmt.Errorf("dingtalk.IteratePunchRecords, invalid cursor %q", cursor)
+			}
+			windowStart = time.Unix(0, startNano).In(from.Location())
+		}
+		if userOffset >= len(userIds) || from.After(to) {
+			return &Page[models.PunchRecord]{}, nil
+		}
+		end := userOffset + punchRecordMaxUsers
+		if end > len(userIds) {
+			end = len(userIds)
+		}
+		windowEnd := windowStart.AddDate(0, 0, punchRecordMaxDays).Add(-time.Second)
+		if windowEnd.After(to) {
+			windowEnd = to
+		}
+		records, err := c.listPunchRecords(ctx, userIds[userOffset:end], windowStart, windowEnd)
+		if err != nil {
+			return nil, err
+		}
+		// 先按时间窗口推进，当前用户批次的时间范围遍历完后再切换到下一批用户
+		windowStart = windowEnd.Add(time.Second)
+		if windowStart.After(to) {
+			windowStart = from
+			userOffset = end
+		}
+		return &Page[models.PunchRecord]{
+			Items:   records,
+			Next:    fmt.Sprintf("%d:%d", userOffset, windowStart.UnixNano()),
+			HasMore: userOffset < len(userIds),
+		}, nil
+	})
+}
+
+// ListPunchRecords 获取 userIds 在 [from, to] 内的所有打卡详情，数据量较大时请使用 IteratePunchRecords
+func (c *Client) ListPunchRecords(ctx context.Context, userIds []string, from, to time.Time) ([]models.PunchRecord, error) {
+	return c.IteratePunchRecords(userIds, from, to).All(ctx)
+}
+
+func (c *Client) listPunchRecords(ctx context.Context, userIds []string, from, to time.Time) ([]models.PunchRecord, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/attendance-clock-in-record-is-open
+	req := models.ListPunchRecordsRequest{
+		UserIds:       userIds,
+		CheckDateFrom: from.Format(attendanceTimeLayout),
+		CheckDateTo:   to.Format(attendanceTimeLayout),
+	}
+	resp := &models.ListPunchRecordsResponse{}
+	if err := c.doTopApi(ctx, "/attendance/listRecord", req, resp); err != nil {
+		return nil, err
+	}
+	return resp.RecordResult, nil
+}
+
+// GetUserAttendanceGroup 获取用户的考勤组和班次
+func (c *Client) GetUserAttendanceGroup(ctx context.Context, userId string) (*models.UserAttendanceGroup, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/query-the-attendance-group-of-a-user
+	req := map[string]string{"userid": userId}
+	resp := &models.UserAttendanceGroup{}
+	if err := c.doTopApi(ctx, "/topapi/attendance/getusergroup", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// IterateAttendanceGroups 遍历企业所有考勤组
+func (c *Client) IterateAttendanceGroups() *Pager[models.AttendanceGroup] {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/queries-attendance-group-list-details
+	return NewPager(OffsetPages(groupPageSize, func(ctx context.Context, offset, size int) ([]models.AttendanceGroup, bool, error) {
+		req := map[string]int{"offset": offset, "size": size}
+		resp := &models.ListAttendanceGroupsResponse{}
+		if err := c.doTopApi(ctx, "/topapi/attendance/getsimplegroups", req, resp); err != nil {
+			return nil, false, err
+		}
+		return resp.Groups, resp.HasMore, nil
+	}))
+}
+
+// ListAttendanceGroups 获取企业所有考勤组
+func (c *Client) ListAttendanceGroups(ctx context.Context) ([]models.AttendanceGroup, error) {
+	return c.IterateAttendanceGroups().All(ctx)
+}
+
+// GetAttendanceShift 获取班次详情，opUserId 为操作人 userId
+func (c *Client) GetAttendanceShift(ctx context.Context, opUserId string, shiftId int64) (*models.AttendanceShift, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/query-shift-details
+	req := map[string]interface{}{"op_user_id": opUserId, "shift_id": shiftId}
+	resp := &models.AttendanceShift{}
+	if err := c.doTopApi(ctx, "/topapi/attendance/shift/query", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// IterateLeaveStatus 遍历 userIds 在 [start, end] 内的请假状态，内部处理 100 人的限制和分页
+func (c *Client) IterateLeaveStatus(userIds []string, start, end time.Time) *Pager[models.LeaveStatus] {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/query-status-of-leave
+	return NewPager(batchedPages(userIds, leaveStatusMaxUsers, func(batch []string) PageFunc[models.LeaveStatus] {
+		return OffsetPages(leaveStatusPageSize, func(ctx context.Context, offset, size int) ([]models.LeaveStatus, bool, error) {
+			req := map[string]interface{}{
+				"userid_list": strings.Join(batch, ","),
+				"start_time":  start.UnixMilli(),
+				"end_time":    end.UnixMilli(),
+				"offset":      offset,
+				"size":        size,
+			}
+			resp := &models.GetLeaveStatusResponse{}
+			if err := c.doTopApi(ctx, "/topapi/attendance/getleavestatus", req, resp); err != nil {
+				return nil, false, err
+			}
+			return resp.LeaveStatus, resp.HasMore, nil
+		})
+	}))
+}
+
+// GetLeaveStatus 获取 userIds 在 [start, end] 内的请假状态
+func (c *Client) GetLeaveStatus(ctx context.Context, userIds []string, start, end time.Time) ([]models.LeaveStatus, error) {
+	return c.IterateLeaveStatus(userIds, start, end).All(ctx)
+}
+
+// IterateLeaveQuotas 遍历 userIds 的假期余额，leaveCode 为假期类型，opUserId 为操作人 userId
+func (c *Client) IterateLeaveQuotas(opUserId, leaveCode string, userIds []string) *Pager[models.LeaveQuota] {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/query-the-holiday-balance
+	return NewPager(batchedPages(userIds, leaveQuotaMaxUsers, func(batch []string) PageFunc[models.LeaveQuota] {
+		return OffsetPages(leaveQuotaPageSize, func(ctx context.Context, offset, size int) ([]models.LeaveQuota, bool, error) {
+			req := map[string]interface{}{
+				"leave_code": leaveCode,
+				"op_userid":  opUserId,
+				"userids":    strings.Join(batch, ","),
+				"offset":     offset,
+				"size":       size,
+			}
+			resp := &models.ListLeaveQuotasResponse{}
+			if err := c.doTopApi(ctx, "/topapi/attendance/vacation/quota/list", req, resp); err != nil {
+				return nil, false, err
+			}
+			return resp.LeaveQuotas, resp.HasMore, nil
+		})
+	}))
+}
+
+// ListLeaveQuotas 获取 userIds 的假期余额，leaveCode 为假期类型，opUserId 为操作人 userId
+func (c *Client) ListLeaveQuotas(ctx context.Context, opUserId, leaveCode string, userIds []string) ([]models.LeaveQuota, error) {
+	return c.IterateLeaveQuotas(opUserId, leaveCode, userIds).All(ctx)
+}
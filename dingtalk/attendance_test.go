@@ -0,0 +1,172 @@
+package dingtalk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+func testUserIds(n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("u%03d", i)
+	}
+	return ids
+}
+
+func TestIteratePunchRecords(t *testing.T) {
+	type window struct {
+		users    int
+		first    string
+		from, to string
+	}
+	var windows []window
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/attendance/listRecord":
+			req := &models.ListPunchRecordsRequest{}
+			_ = json.NewDecoder(r.Body).Decode(req)
+			windows = append(windows, window{len(req.UserIds), req.UserIds[0], req.CheckDateFrom, req.CheckDateTo})
+			_, _ = fmt.Fprintf(w, `{"errcode":0,"recordresult":[{"id":%d,"userId":%q,"userCheckTime":1709258400000}]}`,
+				len(windows), req.UserIds[0])
+		}
+	})
+	loc := time.FixedZone("CST", 8*3600)
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, loc)
+	to := time.Date(2024, 3, 10, 23, 59, 59, 0, loc)
+
+	// 120 人分为 50、50、20 三批，10 天分为 7 天和 3 天两个窗口
+	records, err := client.ListPunchRecords(context.Background(), testUserIds(120), from, to)
+	if err != nil {
+		t.Fatalf("ListPunchRecords() error = %v", err)
+	}
+	want := []window{
+		{50, "u000", "2024-03-01 00:00:00", "2024-03-07 23:59:59"},
+		{50, "u000", "2024-03-08 00:00:00", "2024-03-10 23:59:59"},
+		{50, "u050", "2024-03-01 00:00:00", "2024-03-07 23:59:59"},
+		{50, "u050", "2024-03-08 00:00:00", "2024-03-10 23:59:59"},
+		{20, "u100", "2024-03-01 00:00:00", "2024-03-07 23:59:59"},
+		{20, "u100", "2024-03-08 00:00:00", "2024-03-10 23:59:59"},
+	}
+	if fmt.Sprint(windows) != fmt.Sprint(want) {
+		t.Errorf("windows = %v\nwant %v", windows, want)
+	}
+	if len(records) != 6 || records[5].UserID != "u100" || records[0].UserCheckTime.IsZero() {
+		t.Errorf("records = %+v", records)
+	}
+
+	windows = nil
+	if records, err := client.ListPunchRecords(context.Background(), testUserIds(3), to, from); err != nil || len(records) != 0 || len(windows) != 0 {
+		t.Errorf("ListPunchRecords(from > to) = %v, %v, requests = %d", records, err, len(windows))
+	}
+}
+
+func TestIterateAttendanceGroups(t *testing.T) {
+	var offsets []int
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/topapi/attendance/getsimplegroups":
+			req := map[string]int{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req["size"] != groupPageSize {
+				t.Errorf("size = %d", req["size"])
+			}
+			offsets = append(offsets, req["offset"])
+			if req["offset"] == 0 {
+				_, _ = w.Write([]byte(`{"errcode":0,"result":{"groups":[{"group_id":1,"group_name":"研发"}],"has_more":true}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"errcode":0,"result":{"groups":[{"group_id":2,"group_name":"销售"}],"has_more":false}}`))
+		}
+	})
+	groups, err := client.ListAttendanceGroups(context.Background())
+	if err != nil {
+		t.Fatalf("ListAttendanceGroups() error = %v", err)
+	}
+	if len(groups) != 2 || groups[1].GroupName != "销售" || fmt.Sprint(offsets) != "[0 10]" {
+		t.Errorf("groups = %+v, offsets = %v", groups, offsets)
+	}
+}
+
+func TestIterateLeaveStatus(t *testing.T) {
+	type call struct {
+		users  int
+		first  string
+		offset int
+	}
+	var calls []call
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/topapi/attendance/getleavestatus":
+			req := struct {
+				UserIDList string `json:"userid_list"`
+				Offset     int    `json:"offset"`
+				Size       int    `json:"size"`
+			}{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			users := strings.Split(req.UserIDList, ",")
+			calls = append(calls, call{len(users), users[0], req.Offset})
+			// 第一批用户有两页，第二批只有一页
+			hasMore := users[0] == "u000" && req.Offset == 0
+			_, _ = fmt.Fprintf(w, `{"errcode":0,"result":{"leave_status":[{"userid":%q,"duration_unit":"percent_day","duration_percent":100}],"has_more":%t}}`,
+				users[req.Offset/leaveStatusPageSize], hasMore)
+		}
+	})
+	statuses, err := client.GetLeaveStatus(context.Background(), testUserIds(150), time.Now(), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetLeaveStatus() error = %v", err)
+	}
+	want := []call{{100, "u000", 0}, {100, "u000", 20}, {50, "u100", 0}}
+	if fmt.Sprint(calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	if len(statuses) != 3 || statuses[1].UserID != "u001" || statuses[2].UserID != "u100" {
+		t.Errorf("statuses = %+v", statuses)
+	}
+}
+
+func TestIterateLeaveQuotasError(t *testing.T) {
+	var calls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/topapi/attendance/vacation/quota/list":
+			// 第一批正常返回，第二批没有权限
+			if atomic.AddInt32(&calls, 1) == 1 {
+				_, _ = w.Write([]byte(`{"errcode":0,"result":{"leave_quotas":[{"userid":"u000","leave_code":"annual","quota_num_per_day":500}],"has_more":false}}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"errcode":60011,"errmsg":"no permission"}`))
+		}
+	})
+	pager := client.IterateLeaveQuotas("admin", "annual", testUserIds(60))
+	defer pager.Close()
+	var quotas []models.LeaveQuota
+	for pager.Next(context.Background()) {
+		quotas = append(quotas, pager.Item())
+	}
+	if len(quotas) != 1 || quotas[0].QuotaNumPerDay != 500 {
+		t.Errorf("quotas = %+v", quotas)
+	}
+	if err := pager.Err(); ErrorKindOf(err) != ErrorKindPermission {
+		t.Errorf("pager.Err() = %v, want permission error", err)
+	}
+	// 没有权限不重试
+	if n := atomic.LoadInt32(&calls); n != 2 {
+		t.Errorf("quota calls = %d, want 2", n)
+	}
+}
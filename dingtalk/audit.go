@@ -0,0 +1,177 @@
+package dingtalk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	url2 "net/url"
+	"strings"
+	"time"
+
+	"github.com/chzealot/gobase/logger"
+)
+
+const (
+	defaultAuditBodySize = 2048
+	maskedValue          = "******"
+)
+
+// 日志中需要隐藏的参数和 JSON 字段，比较时忽略大小写
+var sensitiveKeys = map[string]bool{
+	"access_token":       true,
+	"accesstoken":        true,
+	"suite_access_token": true,
+	"appsecret":          true,
+	"clientsecret":       true,
+	"client_secret":      true,
+	"suite_secret":       true,
+	"suitesecret":        true,
+	"suiteticket":        true,
+	"suite_ticket":       true,
+	"code":               true,
+	"authcode":           true,
+	"tmp_auth_code":      true,
+	"refresh_token":      true,
+	"refreshtoken":       true,
+	"ticket":             true,
+	"signature":          true,
+}
+
+func isSensitiveKey(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// AuditTransport 记录每次请求的方法、接口模板、状态码、耗时、钉钉 requestId 和截断后的请求、响应内容，
+// access_token、appsecret、clientSecret、code、refresh token 等敏感字段始终会被隐藏。
+// 只记录 JSON 格式的内容，上传、下载的文件内容不会被读取
+type AuditTransport struct {
+	// Base 为 nil 时使用 http.DefaultTransport
+	Base http.RoundTripper
+	// MaxBodySize 请求、响应内容记录的最大长度，默认 2048
+	MaxBodySize int
+}
+
+func (t *AuditTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ctx := r.Context()
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	endpoint := endpointFromContext(ctx)
+	if endpoint == "" {
+		endpoint = r.URL.Path
+	}
+	fields := []interface{}{
+		"method", r.Method,
+		"host", r.URL.Host,
+		"endpoint", endpoint,
+		"query", maskQuery(r.URL.Query()),
+	}
+	if isJSON(r.Header.Get("Content-Type")) && r.GetBody != nil {
+		if body, err := r.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			_ = body.Close()
+			fields = append(fields, "request", t.maskBody(data, false))
+		}
+	}
+
+	start := time.Now()
+	res, err := base.RoundTrip(r)
+	fields = append(fields, "latency", time.Since(start).String())
+	if err != nil {
+		fields = append(fields, "error", err)
+		logger.WarnwCtx(ctx, "dingtalk.audit", fields...)
+		return nil, err
+	}
+	fields = append(fields, "status", res.StatusCode)
+	requestId := res.Header.Get("x-acs-request-id")
+	if isJSON(res.Header.Get("Content-Type")) {
+		data, readErr := io.ReadAll(res.Body)
+		_ = res.Body.Close()
+		res.Body = io.NopCloser(bytes.NewReader(data))
+		if readErr != nil {
+			fields = append(fields, "error", readErr)
+		}
+		if requestId == "" {
+			requestId = requestIDFromBody(data)
+		}
+		fields = append(fields, "response", t.maskBody(data, true))
+	} else {
+		fields = append(fields, "responseType", res.Header.Get("Content-Type"))
+	}
+	fields = append(fields, "requestId", requestId)
+	if res.StatusCode >= 400 {
+		logger.WarnwCtx(ctx, "dingtalk.audit", fields...)
+	} else {
+		logger.InfowCtx(ctx, "dingtalk.audit", fields...)
+	}
+	return res, nil
+}
+
+// maskBody 隐藏敏感字段后截断，响应中的 code 为错误码，不需要隐藏
+func (t *AuditTransport) maskBody(data []byte, response bool) string {
+	limit := t.MaxBodySize
+	if limit <= 0 {
+		limit = defaultAuditBodySize
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		// 无法解析时不记录原文，避免泄露敏感信息
+		return fmt.Sprintf("<%d bytes, invalid json>", len(data))
+	}
+	masked, _ := json.Marshal(maskJSON(v, response))
+	if len(masked) > limit {
+		return string(masked[:limit]) + fmt.Sprintf("...(%d bytes)", len(masked))
+	}
+	return string(masked)
+}
+
+func maskJSON(v interface{}, response bool) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for k, child := range value {
+			if isSensitiveKey(k) && !(response && k == "code") {
+				value[k] = maskedValue
+			} else {
+				value[k] = maskJSON(child, response)
+			}
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = maskJSON(child, response)
+		}
+	}
+	return v
+}
+
+func maskQuery(query url2.Values) string {
+	for k := range query {
+		if isSensitiveKey(k) {
+			query[k] = []string{maskedValue}
+		}
+	}
+	return query.Encode()
+}
+
+func requestIDFromBody(data []byte) string {
+	ids := struct {
+		RequestID  string `json:"request_id"`
+		RequestId2 string `json:"requestid"`
+		RequestId3 string `json:"requestId"`
+	}{}
+	if json.Unmarshal(data, &ids) != nil {
+		return ""
+	}
+	for _, id := range []string{ids.RequestID, ids.RequestId2, ids.RequestId3} {
+		if id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+func isJSON(contentType string) bool {
+	return strings.Contains(strings.ToLower(contentType), "json")
+}
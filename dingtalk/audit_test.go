@@ -0,0 +1,59 @@
+package dingtalk
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/chzealot/gobase/logger"
+)
+
+func TestAuditMasking(t *testing.T) {
+	query := maskQuery(url.Values{"appkey": {"key"}, "appsecret": {"s3cret"}, "access_token": {"t0k3n"}})
+	if strings.Contains(query, "s3cret") || strings.Contains(query, "t0k3n") || !strings.Contains(query, "appkey=key") {
+		t.Errorf("query = %s", query)
+	}
+
+	audit := &AuditTransport{MaxBodySize: 64}
+	req := audit.maskBody([]byte(`{"clientSecret":"s3cret","code":"c0de","grantType":"authorization_code","nested":[{"refreshToken":"r"}]}`), false)
+	for _, secret := range []string{"s3cret", "c0de", `"r"`} {
+		if strings.Contains(req, secret) {
+			t.Errorf("request body leaks %s: %s", secret, req)
+		}
+	}
+	resp := audit.maskBody([]byte(`{"code":"Forbidden","accessToken":"tok"}`), true)
+	if !strings.Contains(resp, "Forbidden") || strings.Contains(resp, "tok\"") {
+		t.Errorf("response body = %s", resp)
+	}
+	long := audit.maskBody([]byte(`{"data":"`+strings.Repeat("x", 200)+`"}`), true)
+	if !strings.HasSuffix(long, "...(211 bytes)") {
+		t.Errorf("truncated body = %s", long)
+	}
+	if got := audit.maskBody([]byte("access_token=tok"), false); strings.Contains(got, "tok") {
+		t.Errorf("non-json body = %s", got)
+	}
+}
+
+func TestAuditTransportPreservesBody(t *testing.T) {
+	_ = logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"errcode":0,"access_token":"tok","expires_in":7200,"request_id":"r1"}`))
+	}))
+	defer server.Close()
+	target, _ := url.Parse(server.URL)
+	client := NewDingTalkClient("id", "secret", WithAuditLog(0),
+		WithHTTPClient(&http.Client{Transport: &rewriteTransport{target: target}}))
+	if _, ok := client.httpClient.Transport.(*AuditTransport); !ok {
+		t.Fatalf("transport = %T", client.httpClient.Transport)
+	}
+	token, err := client.GetAccessToken()
+	if err != nil || token != "tok" {
+		t.Fatalf("token=%s, err=%v", token, err)
+	}
+	if got := requestIDFromBody([]byte(`{"requestid":"r2"}`)); got != "r2" {
+		t.Errorf("requestId = %s", got)
+	}
+}
@@ -0,0 +1,71 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/chzealot/gobase/logger"
+)
+
+// HeaderAuthCode 前端通过 header 传递免登授权码时使用的 header
+const HeaderAuthCode = "X-DingTalk-Auth-Code"
+
+// 不导出类型，防止外部包覆盖
+type contextKey struct{ name string }
+
+var userInfoKey = contextKey{"dingtalk_user"}
+
+// GetUserInfoByAuthCode 使用 H5 微应用或小程序 dd.runtime.permission.requestAuthCode 获取的免登授权码换取用户身份，
+// 授权码只能使用一次，有效期 5 分钟
+func (c *Client) GetUserInfoByAuthCode(ctx context.Context, authCode string) (*models.AuthCodeUserInfo, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/obtain-the-userid-of-a-user-by-using-the-log-free
+	if authCode == "" {
+		return nil, errors.New("dingtalk.GetUserInfoByAuthCode, empty authCode")
+	}
+	req := map[string]string{"code": authCode}
+	resp := &models.AuthCodeUserInfo{}
+	if err := c.doTopApi(ctx, "/topapi/v2/user/getuserinfo", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// WithUserInfo 将钉钉用户身份添加到 context 中
+func WithUserInfo(ctx context.Context, info *models.AuthCodeUserInfo) context.Context {
+	return context.WithValue(ctx, userInfoKey, info)
+}
+
+// UserInfoFromContext 从 context 中获取 AuthCodeMiddleware 写入的钉钉用户身份
+func UserInfoFromContext(ctx context.Context) (*models.AuthCodeUserInfo, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	info, ok := ctx.Value(userInfoKey).(*models.AuthCodeUserInfo)
+	return info, ok && info != nil
+}
+
+// AuthCodeMiddleware 从 query 参数 authCode 或 header X-DingTalk-Auth-Code 读取免登授权码，
+// 换取用户身份后写入 request context，handler 通过 UserInfoFromContext 读取。
+// 缺少授权码或授权码无效时返回 401
+func (c *Client) AuthCodeMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		authCode := r.URL.Query().Get("authCode")
+		if authCode == "" {
+			authCode = r.Header.Get(HeaderAuthCode)
+		}
+		if authCode == "" {
+			http.Error(w, "missing authCode", http.StatusUnauthorized)
+			return
+		}
+		info, err := c.GetUserInfoByAuthCode(ctx, authCode)
+		if err != nil {
+			logger.WarnwCtx(ctx, "dingtalk.AuthCodeMiddleware, exchange authCode failed", "error", err)
+			http.Error(w, "invalid authCode", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithUserInfo(ctx, info)))
+	})
+}
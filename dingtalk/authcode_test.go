@@ -0,0 +1,63 @@
+package dingtalk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestAuthCodeMiddleware(t *testing.T) {
+	var exchanges int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/topapi/v2/user/getuserinfo":
+			atomic.AddInt32(&exchanges, 1)
+			req := map[string]string{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			if req["code"] != "code-1" {
+				_, _ = w.Write([]byte(`{"errcode":40078,"errmsg":"不存在的临时授权码"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"errcode":0,"result":{"userid":"u1","unionid":"union1","name":"张三","sys":true}}`))
+		}
+	})
+	handler := client.AuthCodeMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info, ok := UserInfoFromContext(r.Context())
+		if !ok {
+			t.Error("UserInfoFromContext() ok = false")
+			return
+		}
+		_, _ = w.Write([]byte(info.UserID + "/" + info.UnionID))
+	}))
+	serve := func(target string, header string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if header != "" {
+			r.Header.Set(HeaderAuthCode, header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := serve("/profile?authCode=code-1", ""); w.Code != http.StatusOK || w.Body.String() != "u1/union1" {
+		t.Errorf("query authCode: status = %d, body = %q", w.Code, w.Body.String())
+	}
+	if w := serve("/profile", "code-1"); w.Code != http.StatusOK || w.Body.String() != "u1/union1" {
+		t.Errorf("header authCode: status = %d, body = %q", w.Code, w.Body.String())
+	}
+
+	if w := serve("/profile", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("missing authCode: status = %d, want 401", w.Code)
+	}
+	if w := serve("/profile?authCode=used", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("invalid authCode: status = %d, want 401", w.Code)
+	}
+	// 缺少授权码时不请求钉钉
+	if n := atomic.LoadInt32(&exchanges); n != 3 {
+		t.Errorf("getuserinfo calls = %d, want 3", n)
+	}
+}
@@ -0,0 +1,69 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+// PrimaryCalendarID 用户的主日历
+const PrimaryCalendarID = "primary"
+
+const onlineMeetingTypeDingTalk = "dingtalk"
+
+// defaultEventTimeZone 与 models.DefaultLocation 对应的 IANA 时区名
+const defaultEventTimeZone = "Asia/Shanghai"
+
+// CalendarEventOption 用于 CreateCalendarEvent 的可选配置
+type CalendarEventOption func(*models.CreateCalendarEventRequest)
+
+// WithOnlineMeeting 创建日程时同时创建钉钉视频会议，入会链接在返回日程的 OnlineMeetingInfo 中
+func WithOnlineMeeting() CalendarEventOption {
+	return func(req *models.CreateCalendarEventRequest) {
+		req.OnlineMeetingInfo = &models.CreateEventOnlineMeeting{Type: onlineMeetingTypeDingTalk}
+	}
+}
+
+// WithAttendees 添加日程参与人
+func WithAttendees(unionIds ...string) CalendarEventOption {
+	return func(req *models.CreateCalendarEventRequest) {
+		for _, unionId := range unionIds {
+			req.Attendees = append(req.Attendees, models.CreateEventAttendee{ID: unionId})
+		}
+	}
+}
+
+// EventDateTime 非全天日程的时间，时区取 t 的时区；t 为 Local 或 UTC 时按东八区（Asia/Shanghai）设置
+func EventDateTime(t time.Time) models.CreateEventTime {
+	zone := t.Location().String()
+	if zone == "Local" || zone == "UTC" || zone == "" {
+		t = t.In(models.DefaultLocation)
+		zone = defaultEventTimeZone
+	}
+	return models.CreateEventTime{DateTime: t.Format(time.RFC3339), TimeZone: zone}
+}
+
+// EventDate 全天日程的日期，结束日期为最后一天的后一天
+func EventDate(t time.Time) models.CreateEventTime {
+	return models.CreateEventTime{Date: t.In(models.DefaultLocation).Format("2006-01-02")}
+}
+
+// CreateCalendarEvent 在 unionId 的主日历中创建日程，使用应用 access_token，opts 不会修改传入的 req
+func (c *Client) CreateCalendarEvent(ctx context.Context, unionId string, req *models.CreateCalendarEventRequest,
+	opts ...CalendarEventOption) (*models.CalendarEvent, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/create-event
+	if unionId == "" || req == nil || req.Summary == "" {
+		return nil, errors.New("dingtalk.CreateCalendarEvent, unionId and summary are required")
+	}
+	event := *req
+	event.Attendees = append([]models.CreateEventAttendee(nil), req.Attendees...)
+	for _, opt := range opts {
+		opt(&event)
+	}
+	ep := API(http.MethodPost, "/v1.0/calendar/users/{unionId}/calendars/{calendarId}/events")
+	ep.PathParams = []string{unionId, PrimaryCalendarID}
+	return Do[*models.CreateCalendarEventRequest, models.CalendarEvent](ctx, c, ep, &event)
+}
@@ -0,0 +1,72 @@
+package dingtalk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+func TestEventDateTime(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata: %v", err)
+	}
+	at := time.Date(2026, 10, 19, 9, 30, 0, 0, time.UTC)
+	tests := []struct {
+		t    time.Time
+		want models.CreateEventTime
+	}{
+		{at, models.CreateEventTime{DateTime: "2026-10-19T17:30:00+08:00", TimeZone: "Asia/Shanghai"}},
+		{at.Local(), models.CreateEventTime{DateTime: "2026-10-19T17:30:00+08:00", TimeZone: "Asia/Shanghai"}},
+		{at.In(newYork), models.CreateEventTime{DateTime: "2026-10-19T05:30:00-04:00", TimeZone: "America/New_York"}},
+	}
+	for _, tt := range tests {
+		if got := EventDateTime(tt.t); got != tt.want {
+			t.Errorf("EventDateTime(%v) = %+v, want %+v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestCreateCalendarEvent(t *testing.T) {
+	var created models.CreateCalendarEventRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/v1.0/calendar/users/union1/calendars/primary/events":
+			created = models.CreateCalendarEventRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			_, _ = w.Write([]byte(`{"id":"event1","summary":"weekly","onlineMeetingInfo":{"type":"dingtalk","url":"https://meeting"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	if _, err := client.CreateCalendarEvent(ctx, "union1", &models.CreateCalendarEventRequest{}); err == nil {
+		t.Error("CreateCalendarEvent() without summary error = nil")
+	}
+	start := time.Now().Add(time.Hour)
+	req := &models.CreateCalendarEventRequest{
+		Summary:   "weekly",
+		Start:     EventDateTime(start),
+		End:       EventDateTime(start.Add(time.Hour)),
+		Attendees: []models.CreateEventAttendee{{ID: "union2"}},
+	}
+	event, err := client.CreateCalendarEvent(ctx, "union1", req, WithOnlineMeeting(), WithAttendees("union3"))
+	if err != nil || event.ID != "event1" || event.OnlineMeetingInfo.Url != "https://meeting" {
+		t.Fatalf("CreateCalendarEvent() = %+v, %v", event, err)
+	}
+	if created.OnlineMeetingInfo == nil || created.OnlineMeetingInfo.Type != "dingtalk" ||
+		len(created.Attendees) != 2 || created.Attendees[0].ID != "union2" || created.Attendees[1].ID != "union3" {
+		t.Errorf("create request = %+v", created)
+	}
+	// opts 作用于副本，调用方的 req 可以复用
+	if req.OnlineMeetingInfo != nil || len(req.Attendees) != 1 {
+		t.Errorf("CreateCalendarEvent() modified req = %+v", req)
+	}
+}
@@ -0,0 +1,179 @@
+package card
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/chzealot/gobase/dingtalk/robot"
+	"github.com/chzealot/gobase/logger"
+)
+
+// Callback 一次按钮点击的回传
+type Callback struct {
+	Request  *models.CardCallbackRequest
+	ActionID string
+	Params   map[string]interface{}
+}
+
+// Param 以字符串形式读取回传参数，不存在时返回空字符串
+func (cb *Callback) Param(key string) string {
+	value, ok := cb.Params[key]
+	if !ok || value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprint(value)
+}
+
+// ActionFunc 处理按钮点击，返回的 CardCallbackResponse 用于更新卡片，可以为 nil
+type ActionFunc func(ctx context.Context, cb *Callback) (*models.CardCallbackResponse, error)
+
+const maxBodySize = 1 << 20
+
+// ErrNoVerifier 未设置 Verifier 时 ServeHTTP 拒绝所有回传
+var ErrNoVerifier = errors.New("card: no verifier for http callback")
+
+// Verifier 校验 HTTP 回传请求的来源，返回错误时拒绝请求
+type Verifier func(r *http.Request) error
+
+// SignVerifier 按机器人回调的方式校验 header 中的 timestamp 和 sign，secret 为应用的 ClientSecret
+func SignVerifier(secret string) Verifier {
+	return func(r *http.Request) error {
+		return robot.VerifySign(r.Header.Get("timestamp"), r.Header.Get("sign"), secret, time.Now())
+	}
+}
+
+// Router 根据卡片按钮的 actionId 将回传分发到对应的处理函数
+type Router struct {
+	mutex    sync.RWMutex
+	handlers map[string]ActionFunc
+	fallback ActionFunc
+	verifier Verifier
+}
+
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]ActionFunc)}
+}
+
+// Handle 注册 actionId 对应的处理函数
+func (r *Router) Handle(actionId string, fn ActionFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.handlers[actionId] = fn
+}
+
+// HandleDefault 注册未匹配任何 actionId 时的处理函数
+func (r *Router) HandleDefault(fn ActionFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.fallback = fn
+}
+
+// Verify 设置 HTTP 回传的校验方式，未设置时 ServeHTTP 拒绝所有请求；Stream 模式的回传不需要校验
+func (r *Router) Verify(verifier Verifier) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.verifier = verifier
+}
+
+// Dispatch 解析回传内容并调用对应的处理函数
+func (r *Router) Dispatch(ctx context.Context, req *models.CardCallbackRequest) (*models.CardCallbackResponse, error) {
+	cb, err := ParseCallback(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mutex.RLock()
+	fn, ok := r.handlers[cb.ActionID]
+	if !ok {
+		fn = r.fallback
+	}
+	r.mutex.RUnlock()
+	if fn == nil {
+		return nil, fmt.Errorf("card.Router, no handler for action %q", cb.ActionID)
+	}
+	return fn(ctx, cb)
+}
+
+// DispatchJSON 处理 JSON 格式的回传数据，供 Stream 模式等非 HTTP 场景使用
+func (r *Router) DispatchJSON(ctx context.Context, data []byte) ([]byte, error) {
+	req := &models.CardCallbackRequest{}
+	if err := json.Unmarshal(data, req); err != nil {
+		return nil, err
+	}
+	resp, err := r.Dispatch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		resp = &models.CardCallbackResponse{}
+	}
+	return json.Marshal(resp)
+}
+
+// ServeHTTP 接收 HTTP 模式的卡片回传，校验未通过时返回 403
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	r.mutex.RLock()
+	verifier := r.verifier
+	r.mutex.RUnlock()
+	err := ErrNoVerifier
+	if verifier != nil {
+		err = verifier(req)
+	}
+	if err != nil {
+		logger.WarnwCtx(req.Context(), "card.Router, verify callback failed", "error", err)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxBodySize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	respBytes, err := r.DispatchJSON(req.Context(), body)
+	if err != nil {
+		logger.ErrorwCtx(req.Context(), "card.Router, dispatch callback failed", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(respBytes)
+}
+
+// ParseCallback 解码回传中的 actionId 和参数
+func ParseCallback(req *models.CardCallbackRequest) (*Callback, error) {
+	raw := req.Content
+	if raw == "" {
+		raw = req.Value
+	}
+	content := &models.CardCallbackContent{}
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), content); err != nil {
+			return nil, fmt.Errorf("card.ParseCallback, invalid content: %w", err)
+		}
+	}
+	cb := &Callback{
+		Request: req,
+		Params:  content.CardPrivateData.Params,
+	}
+	if len(content.CardPrivateData.ActionIDs) > 0 {
+		cb.ActionID = content.CardPrivateData.ActionIDs[0]
+	}
+	if cb.Params == nil {
+		cb.Params = make(map[string]interface{})
+	}
+	return cb, nil
+}
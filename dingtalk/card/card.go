@@ -0,0 +1,163 @@
+package card
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk"
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+const (
+	CallbackTypeStream = "STREAM"
+	CallbackTypeHTTP   = "HTTP"
+)
+
+// Client 互动卡片接口，复用 dingtalk.Client 的应用凭证
+type Client struct {
+	client *dingtalk.Client
+}
+
+func NewClient(client *dingtalk.Client) *Client {
+	return &Client{client: client}
+}
+
+// GroupSpaceID 群聊投放空间，openConversationId 为群会话 ID
+func GroupSpaceID(openConversationId string) string {
+	return "dtv1.card//IM_GROUP." + openConversationId
+}
+
+// RobotSpaceID 机器人单聊投放空间
+func RobotSpaceID(userId string) string {
+	return "dtv1.card//IM_ROBOT." + userId
+}
+
+// Params 将任意值转换为 cardParamMap，非字符串的值会序列化为 JSON 字符串
+func Params(values map[string]interface{}) (map[string]string, error) {
+	params := make(map[string]string, len(values))
+	for key, value := range values {
+		if s, ok := value.(string); ok {
+			params[key] = s
+			continue
+		}
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("card.Params, marshal %s: %w", key, err)
+		}
+		params[key] = string(b)
+	}
+	return params, nil
+}
+
+// CreateAndDeliver 根据模板创建卡片实例并投放
+func (c *Client) CreateAndDeliver(ctx context.Context, req *models.CreateAndDeliverCardRequest) (*models.CreateAndDeliverCardResult, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/create-and-deliver-cards
+	if req.CardTemplateID == "" || req.OutTrackID == "" || req.OpenSpaceID == "" {
+		return nil, fmt.Errorf("card.CreateAndDeliver, cardTemplateId, outTrackId and openSpaceId are required")
+	}
+	if req.CallbackType == "" {
+		req.CallbackType = CallbackTypeStream
+	}
+	resp := &models.CreateAndDeliverCardResponse{}
+	if err := c.request(ctx, http.MethodPost, "/v1.0/card/instances/createAndDeliver", req, resp); err != nil {
+		return nil, err
+	}
+	return &resp.Result, nil
+}
+
+// UpdateCard 根据 outTrackId 更新卡片公有数据，仅更新 params 中出现的 key
+func (c *Client) UpdateCard(ctx context.Context, outTrackId string, params map[string]string) error {
+	return c.UpdateCardWithRequest(ctx, &models.UpdateCardRequest{
+		OutTrackID: outTrackId,
+		CardData:   &models.CardData{CardParamMap: params},
+		CardUpdateOptions: &models.CardUpdateOptions{
+			UpdateCardDataByKey: true,
+		},
+	})
+}
+
+func (c *Client) UpdateCardWithRequest(ctx context.Context, req *models.UpdateCardRequest) error {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/interactive-card-update-interface
+	resp := &models.UpdateCardResponse{}
+	if err := c.request(ctx, http.MethodPut, "/v1.0/card/instances", req, resp); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("card.UpdateCard, update %s failed", req.OutTrackID)
+	}
+	return nil
+}
+
+// StreamingUpdate 流式更新卡片中 key 对应的内容，适用于 AI 回答逐步输出的场景
+func (c *Client) StreamingUpdate(ctx context.Context, req *models.StreamingUpdateCardRequest) error {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/api-streamingupdate
+	if req.GUID == "" {
+		req.GUID = newGUID()
+	}
+	resp := &models.StreamingUpdateCardResponse{}
+	if err := c.request(ctx, http.MethodPut, "/v1.0/card/streaming", req, resp); err != nil {
+		return err
+	}
+	if !resp.Success {
+		return fmt.Errorf("card.StreamingUpdate, update %s failed", req.OutTrackID)
+	}
+	return nil
+}
+
+// NewStreamer 返回针对某个卡片变量的流式写入器
+func (c *Client) NewStreamer(outTrackId, key string) *Streamer {
+	return &Streamer{client: c, outTrackID: outTrackId, key: key}
+}
+
+// Streamer 对同一卡片变量连续做流式更新，每次写入的是截至当前的全量内容
+type Streamer struct {
+	client     *Client
+	outTrackID string
+	key        string
+	content    bytes.Buffer
+}
+
+// Append 追加内容并推送到卡片
+func (s *Streamer) Append(ctx context.Context, delta string) error {
+	s.content.WriteString(delta)
+	return s.push(ctx, false, false)
+}
+
+// Finish 结束流式输出，卡片进入完成态
+func (s *Streamer) Finish(ctx context.Context) error {
+	return s.push(ctx, true, false)
+}
+
+// Fail 以错误态结束流式输出
+func (s *Streamer) Fail(ctx context.Context) error {
+	return s.push(ctx, true, true)
+}
+
+func (s *Streamer) push(ctx context.Context, finalize, isError bool) error {
+	return s.client.StreamingUpdate(ctx, &models.StreamingUpdateCardRequest{
+		OutTrackID: s.outTrackID,
+		Key:        s.key,
+		Content:    s.content.String(),
+		IsFull:     true,
+		IsFinalize: finalize,
+		IsError:    isError,
+	})
+}
+
+func (c *Client) request(ctx context.Context, method, path string, req, resp interface{}) error {
+	return c.client.Call(ctx, dingtalk.API(method, path), req, resp)
+}
+
+func newGUID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
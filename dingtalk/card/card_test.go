@@ -0,0 +1,245 @@
+package card
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk"
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/chzealot/gobase/dingtalk/robot"
+	"github.com/chzealot/gobase/logger"
+)
+
+func callbackContent(t *testing.T, actionId string, params map[string]interface{}) string {
+	content := models.CardCallbackContent{CardPrivateData: models.CardCallbackPrivateData{
+		ActionIDs: []string{actionId},
+		Params:    params,
+	}}
+	b, err := json.Marshal(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestParseCallback(t *testing.T) {
+	req := &models.CardCallbackRequest{
+		OutTrackID: "track-1",
+		Content:    callbackContent(t, "approve", map[string]interface{}{"reason": "ok", "count": 3}),
+	}
+	cb, err := ParseCallback(req)
+	if err != nil {
+		t.Fatalf("ParseCallback() error = %v", err)
+	}
+	if cb.ActionID != "approve" || cb.Param("reason") != "ok" || cb.Param("count") != "3" || cb.Param("missing") != "" {
+		t.Errorf("ParseCallback() = %+v", cb)
+	}
+
+	// Stream 模式下回传内容在 value 字段中
+	cb, err = ParseCallback(&models.CardCallbackRequest{Value: callbackContent(t, "reject", nil)})
+	if err != nil || cb.ActionID != "reject" || cb.Params == nil {
+		t.Errorf("ParseCallback(value) = %+v, %v", cb, err)
+	}
+
+	if _, err := ParseCallback(&models.CardCallbackRequest{Content: "{"}); err == nil {
+		t.Error("ParseCallback(invalid) error = nil")
+	}
+}
+
+func TestRouterServeHTTP(t *testing.T) {
+	_ = logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff})
+	router := NewRouter()
+	router.Handle("approve", func(ctx context.Context, cb *Callback) (*models.CardCallbackResponse, error) {
+		return &models.CardCallbackResponse{
+			CardData: &models.CardData{CardParamMap: map[string]string{"status": "approved by " + cb.Request.UserID + " " + cb.Param("reason")}},
+		}, nil
+	})
+
+	sign := func(timestamp string) func(r *http.Request) {
+		return func(r *http.Request) {
+			r.Header.Set("timestamp", timestamp)
+			r.Header.Set("sign", robot.Sign(timestamp, "secret"))
+		}
+	}
+	signed := sign(strconv.FormatInt(time.Now().UnixMilli(), 10))
+	post := func(req *models.CardCallbackRequest, header func(r *http.Request)) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(req)
+		r := httptest.NewRequest(http.MethodPost, "/card/callback", bytes.NewReader(body))
+		if header != nil {
+			header(r)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	// 未设置 Verifier 时拒绝所有回传
+	approve := &models.CardCallbackRequest{UserID: "u1", Content: callbackContent(t, "approve", map[string]interface{}{"reason": "ok"})}
+	if w := post(approve, signed); w.Code != http.StatusForbidden {
+		t.Errorf("ServeHTTP() without verifier status = %d, want 403", w.Code)
+	}
+	router.Verify(SignVerifier("secret"))
+	stale := sign(strconv.FormatInt(time.Now().Add(-2*time.Hour).UnixMilli(), 10))
+	for name, header := range map[string]func(r *http.Request){
+		"unsigned": nil,
+		"stale":    stale,
+		"wrong sign": func(r *http.Request) {
+			signed(r)
+			r.Header.Set("sign", robot.Sign(r.Header.Get("timestamp"), "other"))
+		},
+	} {
+		if w := post(approve, header); w.Code != http.StatusForbidden {
+			t.Errorf("ServeHTTP(%s) status = %d, want 403", name, w.Code)
+		}
+	}
+
+	w := post(&models.CardCallbackRequest{UserID: "u1", Content: callbackContent(t, "approve", map[string]interface{}{"reason": "ok"})}, signed)
+	if w.Code != http.StatusOK {
+		t.Fatalf("ServeHTTP() status = %d", w.Code)
+	}
+	resp := &models.CardCallbackResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.CardData == nil || resp.CardData.CardParamMap["status"] != "approved by u1 ok" {
+		t.Errorf("ServeHTTP() response = %s", w.Body.String())
+	}
+
+	if w := post(&models.CardCallbackRequest{Content: callbackContent(t, "unknown", nil)}, signed); w.Code != http.StatusInternalServerError {
+		t.Errorf("ServeHTTP(unknown action) status = %d, want 500", w.Code)
+	}
+
+	router.HandleDefault(func(ctx context.Context, cb *Callback) (*models.CardCallbackResponse, error) {
+		return nil, nil
+	})
+	data, _ := json.Marshal(&models.CardCallbackRequest{Value: callbackContent(t, "unknown", nil)})
+	respBytes, err := router.DispatchJSON(context.Background(), data)
+	if err != nil || string(respBytes) != "{}" {
+		t.Errorf("DispatchJSON(fallback) = %s, %v", respBytes, err)
+	}
+}
+
+func TestCreateAndDeliver(t *testing.T) {
+	_ = logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff})
+	var got models.CreateAndDeliverCardRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/v1.0/card/instances/createAndDeliver":
+			body, _ := io.ReadAll(r.Body)
+			_ = json.Unmarshal(body, &got)
+			_, _ = w.Write([]byte(`{"success":true,"result":{"outTrackId":"track-1","deliverResults":[{"spaceType":"IM_GROUP","spaceId":"cid","success":true}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(dingtalk.NewDingTalkClient("id", "secret", dingtalk.WithBaseURL(server.URL, server.URL)))
+
+	if _, err := client.CreateAndDeliver(context.Background(), &models.CreateAndDeliverCardRequest{}); err == nil {
+		t.Error("CreateAndDeliver() without required fields error = nil")
+	}
+	result, err := client.CreateAndDeliver(context.Background(), &models.CreateAndDeliverCardRequest{
+		CardTemplateID: "tpl",
+		OutTrackID:     "track-1",
+		OpenSpaceID:    GroupSpaceID("cid"),
+	})
+	if err != nil {
+		t.Fatalf("CreateAndDeliver() error = %v", err)
+	}
+	if got.CallbackType != CallbackTypeStream || got.OpenSpaceID != "dtv1.card//IM_GROUP.cid" {
+		t.Errorf("request = %+v", got)
+	}
+	if len(result.DeliverResults) != 1 || !result.DeliverResults[0].Success {
+		t.Errorf("CreateAndDeliver() = %+v", result)
+	}
+}
+
+func TestUpdateCard(t *testing.T) {
+	_ = logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff})
+	var updates []models.UpdateCardRequest
+	var streams []models.StreamingUpdateCardRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/v1.0/card/instances":
+			req := models.UpdateCardRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			updates = append(updates, req)
+			_, _ = w.Write([]byte(`{"success":` + strconv.FormatBool(req.OutTrackID != "missing") + `}`))
+		case "/v1.0/card/streaming":
+			if r.Method != http.MethodPut {
+				t.Errorf("streaming method = %s", r.Method)
+			}
+			req := models.StreamingUpdateCardRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			streams = append(streams, req)
+			_, _ = w.Write([]byte(`{"success":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	client := NewClient(dingtalk.NewDingTalkClient("id", "secret", dingtalk.WithBaseURL(server.URL, server.URL)))
+	ctx := context.Background()
+
+	// 只更新传入的 key
+	if err := client.UpdateCard(ctx, "track-1", map[string]string{"status": "done"}); err != nil {
+		t.Fatalf("UpdateCard() error = %v", err)
+	}
+	got := updates[0]
+	if got.OutTrackID != "track-1" || got.CardData == nil || got.CardData.CardParamMap["status"] != "done" ||
+		got.CardUpdateOptions == nil || !got.CardUpdateOptions.UpdateCardDataByKey {
+		t.Errorf("UpdateCard() request = %+v", got)
+	}
+	if err := client.UpdateCard(ctx, "missing", nil); err == nil {
+		t.Error("UpdateCard() with success=false error = nil")
+	}
+
+	if err := client.StreamingUpdate(ctx, &models.StreamingUpdateCardRequest{OutTrackID: "track-1", Key: "answer", Content: "hi"}); err != nil {
+		t.Fatalf("StreamingUpdate() error = %v", err)
+	}
+	if streams[0].GUID == "" {
+		t.Error("StreamingUpdate() should fill guid")
+	}
+
+	// Streamer 每次推送截至当前的全量内容
+	streams = nil
+	streamer := client.NewStreamer("track-1", "answer")
+	for _, delta := range []string{"你好", "，世界"} {
+		if err := streamer.Append(ctx, delta); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	if err := streamer.Finish(ctx); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+	if err := client.NewStreamer("track-2", "answer").Fail(ctx); err != nil {
+		t.Fatalf("Fail() error = %v", err)
+	}
+	want := []struct {
+		content           string
+		finalize, isError bool
+	}{{"你好", false, false}, {"你好，世界", false, false}, {"你好，世界", true, false}, {"", true, true}}
+	if len(streams) != len(want) {
+		t.Fatalf("streaming updates = %+v", streams)
+	}
+	for i, w := range want {
+		s := streams[i]
+		if s.Content != w.content || s.IsFinalize != w.finalize || s.IsError != w.isError || !s.IsFull || s.Key != "answer" {
+			t.Errorf("streaming update %d = %+v, want %+v", i, s, w)
+		}
+	}
+	if streams[0].GUID == streams[1].GUID {
+		t.Error("each streaming update should use a new guid")
+	}
+}
@@ -1,14 +1,11 @@
 package dingtalk
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
 	"github.com/chzealot/gobase/dingtalk/models"
 	"github.com/chzealot/gobase/logger"
 	"go.uber.org/zap"
-	"io"
 	"net/http"
 	url2 "net/url"
 	"sync"
@@ -20,16 +17,68 @@ const defaultTimeout = time.Second * 60
 type Client struct {
 	ClientID     string
 	ClientSecret string
-	mutex        sync.Mutex
-	expireAt     int64
-	AccessToken  string
+	// AgentID 和 CorpID 仅在需要时设置，如 SignJsapiConfig
+	AgentID     string
+	CorpID      string
+	mutex       sync.Mutex
+	expireAt    int64
+	AccessToken string
+
+	jsapiTicket         string
+	jsapiTicketExpireAt int64
+
+	tokenSource  TokenSource
+	httpClient   *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+
+	audit         bool
+	auditBodySize int
+	limiter       *Limiter
+	oapiBaseURL   string
+	apiBaseURL    string
+	observers     []Observer
+}
+
+// TokenSource 替换默认的 gettoken 接口获取应用 access_token，如第三方企业应用使用的企业凭证，
+// 返回的 token 同样由 GetAccessToken 缓存
+type TokenSource interface {
+	Token() (*models.GetTokenResponse, error)
 }
 
-func NewDingTalkClient(clientId, clientSecret string) *Client {
-	return &Client{
+func NewDingTalkClient(clientId, clientSecret string, opts ...Option) *Client {
+	c := &Client{
 		ClientID:     clientId,
 		ClientSecret: clientSecret,
+		httpClient:   &http.Client{Timeout: defaultTimeout},
+		maxRetries:   defaultMaxRetries,
+		retryBackoff: defaultRetryBackoff,
+		limiter:      NewDefaultLimiter(),
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	c.httpClient = c.wrapTransport(c.httpClient)
+	return c
+}
+
+// Limiter 返回客户端限流器，可通过 State 获取各接口当前的限流状态，未启用时返回 nil
+func (c *Client) Limiter() *Limiter {
+	return c.limiter
+}
+
+// wrapTransport 在所有 Option 生效后为 http.Client 添加审计等 Transport，不修改调用方传入的 http.Client
+func (c *Client) wrapTransport(client *http.Client) *http.Client {
+	if !c.audit {
+		return client
+	}
+	wrapped := *client
+	wrapped.Transport = &AuditTransport{Base: client.Transport, MaxBodySize: c.auditBodySize}
+	return &wrapped
+}
+
+func NewDingTalkClientWithTokenSource(clientId, clientSecret string, source TokenSource, opts ...Option) *Client {
+	return NewDingTalkClient(clientId, clientSecret, append([]Option{WithTokenSource(source)}, opts...)...)
 }
 
 func (c *Client) GetUserAccessToken(code string) (*models.UserAccessTokenResponse, error) {
@@ -40,53 +89,24 @@ func (c *Client) GetUserAccessToken(code string) (*models.UserAccessTokenRespons
 		RefreshToken: "",
 		GrantType:    "authorization_code",
 	}
-	reqBytes, err := json.Marshal(req)
+	ep := API(http.MethodPost, "/v1.0/oauth2/userAccessToken")
+	ep.NoAuth = true
+	resp, err := Do[models.UserAccessTokenRequest, models.UserAccessTokenResponse](context.Background(), c, ep, req)
 	if err != nil {
 		return nil, err
 	}
-	r, err := http.NewRequest("POST", "https://api.dingtalk.com/v1.0/oauth2/userAccessToken", bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, err
-	}
-	r.Header.Add("Content-Type", "application/json")
-	client := &http.Client{}
-	res, err := client.Do(r)
-	if err != nil {
-		panic(err)
-	}
-	respBytes, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
-	resp := &models.UserAccessTokenResponse{}
-	if err = json.Unmarshal(respBytes, &resp); err != nil {
-		return nil, err
-	}
 	resp.ExpireTime = time.Now().Unix() + resp.ExpireIn
 	return resp, nil
 }
 
 func (c *Client) GetContactUser(token string, unionId string) (*models.ContactUser, error) {
-	url := fmt.Sprintf("https://api.dingtalk.com/v1.0/contact/users/%s", url2.QueryEscape(unionId))
-	r, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	r.Header.Add("x-acs-dingtalk-access-token", token)
-	client := &http.Client{}
-	res, err := client.Do(r)
-	if err != nil {
-		panic(err)
-	}
-	respBytes, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
+	ep := API(http.MethodGet, "/v1.0/contact/users/{unionId}")
+	ep.PathParams = []string{unionId}
+	ep.AccessToken = token
 	resp := &models.ContactUser{}
-	if err = json.Unmarshal(respBytes, &resp); err != nil {
+	if err := c.Call(context.Background(), ep, nil, resp); err != nil {
 		return nil, err
 	}
-
 	return resp, nil
 }
 
@@ -140,22 +160,11 @@ func (c *Client) GetCalendars(token string, unionId string) (models.CalendarList
 		unionId = validUnionId
 	}
 
-	r, err := http.NewRequest("GET", fmt.Sprintf("https://api.dingtalk.com/v1.0/calendar/users/%s/calendars", url2.QueryEscape(unionId)), nil)
-	if err != nil {
-		return nil, err
-	}
-	r.Header.Add("x-acs-dingtalk-access-token", token)
-	client := &http.Client{}
-	res, err := client.Do(r)
-	if err != nil {
-		panic(err)
-	}
-	respBytes, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
+	ep := API(http.MethodGet, "/v1.0/calendar/users/{unionId}/calendars")
+	ep.PathParams = []string{unionId}
+	ep.AccessToken = token
 	resp := &models.CalendarResponse{}
-	if err = json.Unmarshal(respBytes, &resp); err != nil {
+	if err := c.Call(context.Background(), ep, nil, resp); err != nil {
 		return nil, err
 	}
 	if resp.CalendarOriginResponse == nil || resp.CalendarOriginResponse.Calendars == nil {
@@ -177,31 +186,35 @@ func (c *Client) GetCalendarEvents(token, unionId, calendarId string) (models.Ca
 	timeMin := today.Format("2006-01-02") + "T00:00:00+08:00"
 	timeMax := today.Format("2006-01-02") + "T23:59:59+08:00"
 
-	url := fmt.Sprintf("https://api.dingtalk.com/v1.0/calendar/users/%s/calendars/%s/events?timeMin=%s&timeMax=%s",
-		url2.QueryEscape(unionId), url2.QueryEscape(calendarId),
-		url2.QueryEscape(timeMin), url2.QueryEscape(timeMax))
-	r, err := http.NewRequest("GET", url, nil)
+	events, err := c.IterateCalendarEvents(token, unionId, calendarId, timeMin, timeMax).All(context.Background())
 	if err != nil {
 		return nil, err
 	}
-	r.Header.Add("x-acs-dingtalk-access-token", token)
-	client := &http.Client{}
-	res, err := client.Do(r)
-	if err != nil {
-		panic(err)
-	}
-	respBytes, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
-	resp := &models.EventResponse{}
-	if err = json.Unmarshal(respBytes, &resp); err != nil {
-		return nil, err
-	}
-	if resp.Events == nil {
-		return nil, errors.New("maybe permission deny")
-	}
-	return resp.Events, nil
+	return events, nil
+}
+
+// IterateCalendarEvents 遍历日历在 [timeMin, timeMax] 内的日程，时间格式为 ISO-8601，token 为用户 token
+func (c *Client) IterateCalendarEvents(token, unionId, calendarId, timeMin, timeMax string) *Pager[*models.CalendarEvent] {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/query-event-list
+	return NewPager(TokenPages(func(ctx context.Context, nextToken string) ([]*models.CalendarEvent, string, error) {
+		ep := API(http.MethodGet, "/v1.0/calendar/users/{unionId}/calendars/{calendarId}/events")
+		ep.PathParams = []string{unionId, calendarId}
+		ep.AccessToken = token
+		ep.Query = url2.Values{}
+		ep.Query.Set("timeMin", timeMin)
+		ep.Query.Set("timeMax", timeMax)
+		if nextToken != "" {
+			ep.Query.Set("nextToken", nextToken)
+		}
+		resp := &models.EventResponse{}
+		if err := c.Call(ctx, ep, nil, resp); err != nil {
+			return nil, "", err
+		}
+		if resp.Events == nil {
+			return nil, "", errors.New("maybe permission deny")
+		}
+		return resp.Events, resp.NextToken, nil
+	}))
 }
 
 func (c *Client) GetAccessToken() (string, error) {
@@ -216,11 +229,28 @@ func (c *Client) GetAccessToken() (string, error) {
 		}
 		c.mutex.Unlock()
 	}
+	for _, o := range c.observers {
+		o.TokenCache(accessToken != "")
+	}
 	if accessToken != "" {
 		return accessToken, nil
 	}
+	return c.refreshAccessToken(context.Background())
+}
 
-	tokenResult, err := c.getAccessTokenFromAPI()
+// refreshAccessToken 不使用缓存，重新获取应用 access_token 并更新缓存。
+// TokenSource 不支持 ctx，仅在调用前检查 ctx 是否已经结束
+func (c *Client) refreshAccessToken(ctx context.Context) (string, error) {
+	var tokenResult *models.GetTokenResponse
+	var err error
+	if c.tokenSource != nil {
+		if err = ctx.Err(); err != nil {
+			return "", err
+		}
+		tokenResult, err = c.tokenSource.Token()
+	} else {
+		tokenResult, err = c.getAccessTokenFromAPI(ctx)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -235,149 +265,60 @@ func (c *Client) GetAccessToken() (string, error) {
 	return tokenResult.AccessToken, nil
 }
 
-func (c *Client) getAccessTokenFromAPI() (*models.GetTokenResponse, error) {
+func (c *Client) getAccessTokenFromAPI(ctx context.Context) (*models.GetTokenResponse, error) {
 	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/obtain-orgapp-token
-	const apiUrl = "https://oapi.dingtalk.com/gettoken"
 	query := url2.Values{}
 	query.Add("appkey", c.ClientID)
 	query.Add("appsecret", c.ClientSecret)
-	fullUrl := apiUrl + "?" + query.Encode()
-
-	// Send the HTTP request and parse the response body as JSON
-	httpClient := http.Client{Timeout: defaultTimeout}
-	res, err := httpClient.Get(fullUrl)
-	if err != nil {
-		return nil, err
-	}
-	defer res.Body.Close()
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
+	ep := Endpoint{Family: FamilyOAPI, Method: http.MethodGet, Path: "/gettoken", Query: query, NoAuth: true}
 	response := &models.GetTokenResponse{}
-	err = json.Unmarshal(body, response)
-	if err != nil {
+	if err := c.Call(ctx, ep, nil, response); err != nil {
+		logger.Errorw("dingtalk.Client, getAccessTokenFromAPI failed", zap.Error(err))
 		return nil, err
 	}
-	if res.StatusCode != http.StatusOK || response.ErrorCode != 0 {
-		logger.Errorw("dingtalk.Client, getAccessTokenFromAPI failed",
-			zap.Int("statusCode", res.StatusCode),
-			zap.Any("response", response))
-		return nil, errors.New(response.ErrorMessage)
-	}
 	return response, nil
 }
 
-func (c *Client) GetUserIDByUnionID(unionId string) (string, error) {
-	appAccessToken, err := c.GetAccessToken()
-	if err != nil {
-		return "", err
-	}
-	url := fmt.Sprintf("https://oapi.dingtalk.com/topapi/user/getbyunionid?access_token=%s", url2.QueryEscape(appAccessToken))
-	params := make(map[string]string, 0)
-	params["unionid"] = unionId
-	reqBytes, err := json.Marshal(params)
-	if err != nil {
-		return "", err
-	}
+// AccessTokenExpireAt 返回缓存的应用 access_token 的过期时间，尚未获取时返回零值
+func (c *Client) AccessTokenExpireAt() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.expireAt == 0 {
+		return time.Time{}
+	}
+	return time.Unix(c.expireAt, 0)
+}
 
-	r, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return "", err
-	}
-	r.Header.Add("Content-Type", "application/json")
-	client := http.Client{Timeout: defaultTimeout}
-	res, err := client.Do(r)
-	if err != nil {
-		panic(err)
-	}
-	respBytes, err := io.ReadAll(res.Body)
+// resetAccessToken 清除缓存的 access_token，下次调用时重新获取
+func (c *Client) resetAccessToken() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.AccessToken = ""
+	c.expireAt = 0
+}
+
+func (c *Client) GetUserIDByUnionID(unionId string) (string, error) {
+	params := map[string]string{"unionid": unionId}
+	resp, err := Do[map[string]string, models.TopGetByUnionIdResponse](context.Background(), c,
+		OAPI("/topapi/user/getbyunionid"), params)
 	if err != nil {
 		return "", err
 	}
-	resp := models.TopResult[models.TopGetByUnionIdResponse]{}
-	if err = json.Unmarshal(respBytes, &resp); err != nil {
-		return "", err
-	}
-
-	return resp.Result.UserID, nil
+	return resp.UserID, nil
 }
 
 func (c *Client) GetUserFromTop(userId string) (*models.TopUser, error) {
-	appAccessToken, err := c.GetAccessToken()
-	if err != nil {
-		return nil, err
-	}
-	url := fmt.Sprintf("https://oapi.dingtalk.com/topapi/v2/user/get?access_token=%s", url2.QueryEscape(appAccessToken))
-	params := make(map[string]string, 0)
-	params["userid"] = userId
-	reqBytes, err := json.Marshal(params)
-	if err != nil {
-		return nil, err
-	}
-
-	r, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, err
-	}
-	r.Header.Add("Content-Type", "application/json")
-	client := http.Client{Timeout: defaultTimeout}
-	res, err := client.Do(r)
-	if err != nil {
-		panic(err)
-	}
-	respBytes, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
-	resp := models.TopResult[models.TopUser]{}
-	if err = json.Unmarshal(respBytes, &resp); err != nil {
-		return nil, err
-	}
-
-	return &resp.Result, nil
+	params := map[string]string{"userid": userId}
+	return Do[map[string]string, models.TopUser](context.Background(), c, OAPI("/topapi/v2/user/get"), params)
 }
 
 func (c *Client) CreateTodoTask(creator, subject string, dueTime time.Time) (*models.CreateTodoTaskResponse, error) {
-	appAccessToken, err := c.GetAccessToken()
-	if err != nil {
-		return nil, err
-	}
-	url := fmt.Sprintf("https://api.dingtalk.com/v1.0/todo/users/%s/tasks?operatorId=%s",
-		url2.QueryEscape(creator),
-		url2.QueryEscape(creator))
-
-	req := models.CreateTodoTaskRequest{
+	req := &models.CreateTodoTaskRequest{
 		Subject:        subject,
 		DueTime:        dueTime.UnixMilli(),
 		CreatorID:      creator,
 		ExecutorIds:    []string{creator},
 		ParticipantIds: []string{creator},
 	}
-	reqBytes, err := json.Marshal(req)
-	if err != nil {
-		return nil, err
-	}
-
-	r, err := http.NewRequest("POST", url, bytes.NewBuffer(reqBytes))
-	if err != nil {
-		return nil, err
-	}
-	r.Header.Add("Content-Type", "application/json")
-	r.Header.Add("x-acs-dingtalk-access-token", appAccessToken)
-	client := http.Client{Timeout: defaultTimeout}
-	res, err := client.Do(r)
-	if err != nil {
-		panic(err)
-	}
-	respBytes, err := io.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
-	}
-	resp := models.CreateTodoTaskResponse{}
-	if err = json.Unmarshal(respBytes, &resp); err != nil {
-		return nil, err
-	}
-
-	return &resp, nil
+	return c.CreateTodo(context.Background(), creator, req)
 }
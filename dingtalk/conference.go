@@ -0,0 +1,94 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	url2 "net/url"
+	"strconv"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+const conferenceMemberPageSize = 20
+
+// CreateVideoConference 发起即时视频会议，unionId 为发起人，inviteUnionIds 为被邀请人，
+// 返回的 ConferenceID 用于查询和结束会议，ExternalLinkURL 为入会链接
+func (c *Client) CreateVideoConference(ctx context.Context, unionId, title string, inviteUnionIds []string) (*models.VideoConference, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/create-a-video-conference
+	if unionId == "" || title == "" {
+		return nil, errors.New("dingtalk.CreateVideoConference, unionId and title are required")
+	}
+	req := models.CreateVideoConferenceRequest{UserID: unionId, ConfTitle: title, InviteUserIds: inviteUnionIds}
+	return Do[models.CreateVideoConferenceRequest, models.VideoConference](ctx, c,
+		API(http.MethodPost, "/v1.0/conference/videoConferences"), req)
+}
+
+// CreateScheduleConference 预约视频会议，到达开始时间后通过返回的 URL 入会
+func (c *Client) CreateScheduleConference(ctx context.Context, creatorUnionId, title string, start, end time.Time) (*models.ScheduleConference, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/create-a-reservation-conference
+	if !end.After(start) {
+		return nil, errors.New("dingtalk.CreateScheduleConference, end must be after start")
+	}
+	req := models.CreateScheduleConferenceRequest{
+		CreatorUnionID: creatorUnionId,
+		Title:          title,
+		StartTime:      models.EpochMillis{Time: start},
+		EndTime:        models.EpochMillis{Time: end},
+	}
+	return Do[models.CreateScheduleConferenceRequest, models.ScheduleConference](ctx, c,
+		API(http.MethodPost, "/v1.0/conference/scheduleConferences"), req)
+}
+
+// CancelScheduleConference 取消预约会议
+func (c *Client) CancelScheduleConference(ctx context.Context, creatorUnionId, scheduleConferenceId string) error {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/cancel-a-scheduled-meeting
+	req := models.CancelScheduleConferenceRequest{CreatorUnionID: creatorUnionId, ScheduleConferenceID: scheduleConferenceId}
+	return c.Call(ctx, API(http.MethodPost, "/v1.0/conference/scheduleConferences/cancel"), req, nil)
+}
+
+// GetVideoConference 查询视频会议信息
+func (c *Client) GetVideoConference(ctx context.Context, conferenceId string) (*models.VideoConferenceInfo, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/query-video-conference-information
+	ep := API(http.MethodGet, "/v1.0/conference/videoConferences/{conferenceId}")
+	ep.PathParams = []string{conferenceId}
+	resp := &models.GetVideoConferenceResponse{}
+	if err := c.Call(ctx, ep, nil, resp); err != nil {
+		return nil, err
+	}
+	return &resp.ConfInfo, nil
+}
+
+// IterateConferenceMembers 遍历视频会议的参会人
+func (c *Client) IterateConferenceMembers(conferenceId string) *Pager[models.ConferenceMember] {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/queries-the-list-of-video-conference-members
+	return NewPager(TokenPages(func(ctx context.Context, nextToken string) ([]models.ConferenceMember, string, error) {
+		ep := API(http.MethodGet, "/v1.0/conference/videoConferences/{conferenceId}/members")
+		ep.PathParams = []string{conferenceId}
+		ep.Query = url2.Values{}
+		ep.Query.Set("maxResults", strconv.Itoa(conferenceMemberPageSize))
+		if nextToken != "" {
+			ep.Query.Set("nextToken", nextToken)
+		}
+		resp := &models.ListConferenceMembersResponse{}
+		if err := c.Call(ctx, ep, nil, resp); err != nil {
+			return nil, "", err
+		}
+		return resp.MemberModels, resp.NextToken, nil
+	}))
+}
+
+// ListConferenceMembers 获取视频会议的所有参会人
+func (c *Client) ListConferenceMembers(ctx context.Context, conferenceId string) ([]models.ConferenceMember, error) {
+	return c.IterateConferenceMembers(conferenceId).All(ctx)
+}
+
+// CloseVideoConference 结束视频会议，unionId 为会议发起人
+func (c *Client) CloseVideoConference(ctx context.Context, conferenceId, unionId string) error {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/close-a-video-conference
+	ep := API(http.MethodDelete, "/v1.0/conference/videoConferences/{conferenceId}")
+	ep.PathParams = []string{conferenceId}
+	ep.Query = url2.Values{"unionId": {unionId}}
+	return c.Call(ctx, ep, nil, nil)
+}
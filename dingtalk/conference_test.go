@@ -0,0 +1,75 @@
+package dingtalk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+func TestVideoConference(t *testing.T) {
+	var created models.CreateVideoConferenceRequest
+	closed := false
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/v1.0/conference/videoConferences":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			_, _ = w.Write([]byte(`{"conferenceId":"conf-1","externalLinkUrl":"https://meeting.example.com/j/1"}`))
+		case "/v1.0/conference/videoConferences/conf-1":
+			if r.Method == http.MethodDelete {
+				// unionId 通过 query 传递，请求体为空
+				body, _ := io.ReadAll(r.Body)
+				if r.URL.Query().Get("unionId") != "union1" || len(body) != 0 {
+					t.Errorf("close request = %s, body %q", r.URL.RawQuery, body)
+				}
+				closed = true
+				_, _ = w.Write([]byte(`{}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"confInfo":{"conferenceId":"conf-1","title":"周会","status":"1",
+				"startTime":1709258400000,"creatorId":"union1","userCount":3}}`))
+		case "/v1.0/conference/videoConferences/conf-1/members":
+			if r.URL.Query().Get("maxResults") != "20" {
+				t.Errorf("members query = %s", r.URL.RawQuery)
+			}
+			if r.URL.Query().Get("nextToken") == "" {
+				_, _ = w.Write([]byte(`{"memberModels":[{"unionId":"union1","host":true},{"unionId":"union2"}],"nextToken":"t2"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"memberModels":[{"unionId":"union3","duration":"60000"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	if _, err := client.CreateVideoConference(ctx, "union1", "", nil); err == nil {
+		t.Error("CreateVideoConference() without title error = nil")
+	}
+	conf, err := client.CreateVideoConference(ctx, "union1", "周会", []string{"union2", "union3"})
+	if err != nil || conf.ConferenceID != "conf-1" || conf.ExternalLinkURL == "" {
+		t.Fatalf("CreateVideoConference() = %+v, %v", conf, err)
+	}
+	if created.UserID != "union1" || created.ConfTitle != "周会" || len(created.InviteUserIds) != 2 {
+		t.Errorf("create request = %+v", created)
+	}
+
+	info, err := client.GetVideoConference(ctx, "conf-1")
+	if err != nil || info.Title != "周会" || info.Status != 1 || info.UserCount != 3 || info.StartTime.IsZero() {
+		t.Errorf("GetVideoConference() = %+v, %v", info, err)
+	}
+
+	members, err := client.ListConferenceMembers(ctx, "conf-1")
+	if err != nil || len(members) != 3 || !members[0].Host || members[2].Duration != 60000 {
+		t.Errorf("ListConferenceMembers() = %+v, %v", members, err)
+	}
+
+	if err := client.CloseVideoConference(ctx, "conf-1", "union1"); err != nil || !closed {
+		t.Errorf("CloseVideoConference() error = %v, closed = %v", err, closed)
+	}
+}
@@ -0,0 +1,180 @@
+// Package digest 生成用户每日日程摘要，渲染为钉钉 markdown 后通过工作通知或机器人发送
+package digest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk"
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+// DefaultTemplate 默认的 markdown 模板，数据为 *Digest
+const DefaultTemplate = `### {{.Title}}
+{{if not .Items}}
+今天没有日程
+{{end}}{{range .Items}}
+- **{{if .AllDay}}全天{{else}}{{clock .Start}} - {{clock .End}}{{end}}** {{.Summary}}
+{{- if .Location}}
+  - 地点：{{.Location}}
+{{- end}}
+{{- if .MeetingRooms}}
+  - 会议室：{{join .MeetingRooms "、"}}
+{{- end}}
+{{- if .OnlineMeetingURL}}
+  - [进入线上会议]({{.OnlineMeetingURL}})
+{{- end}}
+{{end}}`
+
+var templateFuncs = template.FuncMap{
+	"clock": func(t time.Time) string { return t.Format("15:04") },
+	"date":  func(t time.Time) string { return t.Format("2006-01-02") },
+	"join":  strings.Join,
+}
+
+// Recipient 摘要的接收人
+type Recipient struct {
+	// UnionID 用于查询日程
+	UnionID string
+	// UserID 用于发送工作通知，为空时通过 UnionID 查询
+	UserID string
+	// Location 用户所在时区，决定“今天”的范围和时间显示，为 nil 时使用 models.DefaultLocation
+	Location *time.Location
+}
+
+func (r Recipient) location() *time.Location {
+	if r.Location == nil {
+		return models.DefaultLocation
+	}
+	return r.Location
+}
+
+// Item 摘要中的一个日程，时间已转换为接收人的时区
+type Item struct {
+	Start            time.Time
+	End              time.Time
+	AllDay           bool
+	Summary          string
+	Location         string
+	MeetingRooms     []string
+	OnlineMeetingURL string
+}
+
+// Digest 一个用户一天的日程摘要，Text 为渲染后的 markdown
+type Digest struct {
+	Recipient Recipient
+	Day       time.Time
+	Title     string
+	Items     []Item
+	Text      string
+}
+
+// Option 用于 NewBuilder 的可选配置
+type Option func(*Builder) error
+
+// WithTemplate 使用自定义的 markdown 模板，模板数据为 *Digest，可以使用 clock、date、join 函数
+func WithTemplate(text string) Option {
+	return func(b *Builder) error {
+		tmpl, err := template.New("digest").Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return err
+		}
+		b.template = tmpl
+		return nil
+	}
+}
+
+// WithTitle 设置标题格式，参数为日期，默认为 “01月02日 日程”
+func WithTitle(title func(day time.Time) string) Option {
+	return func(b *Builder) error {
+		b.title = title
+		return nil
+	}
+}
+
+// Builder 查询日程并生成摘要
+type Builder struct {
+	client   *dingtalk.Client
+	template *template.Template
+	title    func(day time.Time) string
+}
+
+// NewBuilder 创建摘要生成器，client 使用应用 access_token 查询用户主日历，需要日历读权限
+func NewBuilder(client *dingtalk.Client, opts ...Option) (*Builder, error) {
+	b := &Builder{
+		client:   client,
+		template: template.Must(template.New("digest").Funcs(templateFuncs).Parse(DefaultTemplate)),
+		title: func(day time.Time) string {
+			return day.Format("01月02日") + " 日程"
+		},
+	}
+	for _, opt := range opts {
+		if err := opt(b); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Build 生成 r 在 day 所在日期（按 r 的时区）的日程摘要，日程按开始时间排序，全天日程在前
+func (b *Builder) Build(ctx context.Context, r Recipient, day time.Time) (*Digest, error) {
+	if r.UnionID == "" {
+		return nil, errors.New("digest.Build, unionId is required")
+	}
+	loc := r.location()
+	day = day.In(loc)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	end := start.AddDate(0, 0, 1).Add(-time.Second)
+
+	events, err := b.client.IterateCalendarEvents("", r.UnionID, dingtalk.PrimaryCalendarID,
+		start.Format(time.RFC3339), end.Format(time.RFC3339)).All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	d := &Digest{Recipient: r, Day: start, Title: b.title(start)}
+	for _, event := range events {
+		if event.Status == "cancelled" {
+			continue
+		}
+		d.Items = append(d.Items, newItem(event, loc))
+	}
+	sort.SliceStable(d.Items, func(i, j int) bool {
+		if d.Items[i].AllDay != d.Items[j].AllDay {
+			return d.Items[i].AllDay
+		}
+		return d.Items[i].Start.Before(d.Items[j].Start)
+	})
+
+	buf := &bytes.Buffer{}
+	if err := b.template.Execute(buf, d); err != nil {
+		return nil, err
+	}
+	d.Text = buf.String()
+	return d, nil
+}
+
+func newItem(event *models.CalendarEvent, loc *time.Location) Item {
+	item := Item{
+		AllDay:           event.IsAllDay,
+		Summary:          event.Summary,
+		Location:         event.Location.DisplayName,
+		OnlineMeetingURL: event.OnlineMeetingInfo.Url,
+	}
+	if event.IsAllDay || event.Start.DateTime.IsZero() {
+		item.AllDay = true
+		item.Start = event.Start.Date.Time
+		item.End = event.End.Date.Time
+	} else {
+		item.Start = event.Start.DateTime.In(loc)
+		item.End = event.End.DateTime.In(loc)
+	}
+	for _, room := range event.MeetingRooms {
+		item.MeetingRooms = append(item.MeetingRooms, room.DisplayName)
+	}
+	return item
+}
@@ -0,0 +1,98 @@
+package digest
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/dingtalktest"
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/chzealot/gobase/logger"
+)
+
+func newServer(t *testing.T) *dingtalktest.Server {
+	_ = logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff})
+	s := dingtalktest.NewServer()
+	t.Cleanup(s.Close)
+	s.Store.AddUser(dingtalktest.User{UserID: "user1", UnionID: "union1", Name: "Alice"})
+	return s
+}
+
+func at(day time.Time, hour, minute int) models.EventTime {
+	t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, models.DefaultLocation)
+	return models.EventTime{DateTime: models.Time{Time: t}}
+}
+
+func TestBuild(t *testing.T) {
+	s := newServer(t)
+	day := time.Date(2026, 10, 19, 0, 0, 0, 0, models.DefaultLocation)
+	s.Store.AddEvent("union1", models.CalendarEvent{
+		Summary:           "评审",
+		Start:             at(day, 14, 0),
+		End:               at(day, 15, 0),
+		Location:          models.EventLocation{DisplayName: "A 座"},
+		MeetingRooms:      models.EventMeetingRoomList{{DisplayName: "3F-01"}},
+		OnlineMeetingInfo: models.EventOnlineMeetingInfo{Url: "https://meeting.example.com/1"},
+	})
+	s.Store.AddEvent("union1", models.CalendarEvent{Summary: "站会", Start: at(day, 9, 30), End: at(day, 9, 45)})
+	s.Store.AddEvent("union1", models.CalendarEvent{Summary: "明天", Start: at(day.AddDate(0, 0, 1), 9, 0)})
+
+	builder, err := NewBuilder(s.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := builder.Build(context.Background(), Recipient{UnionID: "union1"}, day.Add(10*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Items) != 2 || d.Items[0].Summary != "站会" || d.Title != "10月19日 日程" {
+		t.Fatalf("digest = %+v", d)
+	}
+	for _, want := range []string{"**09:30 - 09:45** 站会", "**14:00 - 15:00** 评审", "地点：A 座", "会议室：3F-01",
+		"[进入线上会议](https://meeting.example.com/1)"} {
+		if !strings.Contains(d.Text, want) {
+			t.Errorf("missing %q in\n%s", want, d.Text)
+		}
+	}
+
+	// 伦敦时间 10 月 19 日覆盖北京时间 10 月 19 日 07:00 至 10 月 20 日 07:00
+	london := time.FixedZone("BST", 3600)
+	builder, _ = NewBuilder(s.Client(), WithTemplate(`{{range .Items}}{{clock .Start}} {{.Summary}};{{end}}`))
+	d, err = builder.Build(context.Background(), Recipient{UnionID: "union1", Location: london}, day.Add(12*time.Hour))
+	if err != nil || d.Text != "02:30 站会;07:00 评审;" {
+		t.Fatalf("london: text=%q, err=%v", d.Text, err)
+	}
+	if _, err := NewBuilder(s.Client(), WithTemplate("{{")); err == nil {
+		t.Errorf("invalid template should fail")
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	s := newServer(t)
+	client := s.Client()
+	client.AgentID = "1001"
+	builder, _ := NewBuilder(client)
+	scheduler := &Scheduler{
+		Builder:    builder,
+		Sender:     &WorkNotificationSender{Client: client},
+		Recipients: []Recipient{{UnionID: "union1"}},
+		SendAt:     8 * time.Hour,
+	}
+	ctx := context.Background()
+	morning := time.Date(2026, 10, 19, 7, 59, 0, 0, models.DefaultLocation)
+	if n := scheduler.RunOnce(ctx, morning); n != 0 {
+		t.Errorf("before SendAt sent %d", n)
+	}
+	if n := scheduler.RunOnce(ctx, morning.Add(time.Minute)); n != 1 {
+		t.Errorf("at SendAt sent %d", n)
+	}
+	if n := scheduler.RunOnce(ctx, morning.Add(time.Hour)); n != 0 {
+		t.Errorf("same day sent %d again", n)
+	}
+	messages := s.Store.WorkNotifications()
+	if len(messages) != 1 || messages[0].UserIdList != "user1" || messages[0].AgentID != 1001 ||
+		!strings.Contains(messages[0].Msg.Markdown.Text, "今天没有日程") {
+		t.Fatalf("messages = %+v", messages)
+	}
+}
@@ -0,0 +1,90 @@
+package digest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/chzealot/gobase/logger"
+)
+
+const schedulerTick = time.Minute
+
+// Scheduler 每天在接收人所在时区的 SendAt 时刻生成并发送摘要
+//
+//	scheduler := &digest.Scheduler{Builder: builder, Sender: sender, Recipients: recipients, SendAt: 8 * time.Hour}
+//	go scheduler.Run(ctx)
+type Scheduler struct {
+	Builder    *Builder
+	Sender     Sender
+	Recipients []Recipient
+	// SendAt 当天零点之后的发送时刻，如 8*time.Hour+30*time.Minute 为 08:30
+	SendAt time.Duration
+	// SkipEmpty 当天没有日程时不发送
+	SkipEmpty bool
+
+	mutex sync.Mutex
+	// sent 记录每个接收人最后一次发送的日期，避免同一天重复发送
+	sent map[string]string
+}
+
+// Run 每分钟检查一次，直到 ctx 结束，单个接收人失败时记录日志并在下一次检查时重试
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(schedulerTick)
+	defer ticker.Stop()
+	for {
+		s.RunOnce(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 为 now 时已到发送时刻且当天尚未发送的接收人发送摘要，返回发送成功的数量，
+// 可以由外部的 cron 调用
+func (s *Scheduler) RunOnce(ctx context.Context, now time.Time) int {
+	count := 0
+	for _, r := range s.Recipients {
+		local := now.In(r.location())
+		today := local.Format("2006-01-02")
+		midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+		if local.Sub(midnight) < s.SendAt || s.sentOn(r.UnionID) == today {
+			continue
+		}
+		if err := s.send(ctx, r, local); err != nil {
+			logger.WarnwCtx(ctx, "digest.Scheduler, send failed", "unionId", r.UnionID, "error", err)
+			continue
+		}
+		s.markSent(r.UnionID, today)
+		count++
+	}
+	return count
+}
+
+func (s *Scheduler) send(ctx context.Context, r Recipient, day time.Time) error {
+	d, err := s.Builder.Build(ctx, r, day)
+	if err != nil {
+		return err
+	}
+	if s.SkipEmpty && len(d.Items) == 0 {
+		return nil
+	}
+	return s.Sender.Send(ctx, d)
+}
+
+func (s *Scheduler) sentOn(unionId string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.sent[unionId]
+}
+
+func (s *Scheduler) markSent(unionId, day string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.sent == nil {
+		s.sent = map[string]string{}
+	}
+	s.sent[unionId] = day
+}
@@ -0,0 +1,39 @@
+package digest
+
+import (
+	"context"
+
+	"github.com/chzealot/gobase/dingtalk"
+	"github.com/chzealot/gobase/dingtalk/robot"
+)
+
+// Sender 发送摘要
+type Sender interface {
+	Send(ctx context.Context, d *Digest) error
+}
+
+// WorkNotificationSender 以应用身份通过工作通知发送给接收人，Client 需要设置 AgentID
+type WorkNotificationSender struct {
+	Client *dingtalk.Client
+}
+
+func (s *WorkNotificationSender) Send(ctx context.Context, d *Digest) error {
+	userId := d.Recipient.UserID
+	if userId == "" {
+		var err error
+		if userId, err = s.Client.GetUserIDByUnionID(d.Recipient.UnionID); err != nil {
+			return err
+		}
+	}
+	_, err := s.Client.SendMarkdownWorkNotification(ctx, []string{userId}, d.Title, d.Text)
+	return err
+}
+
+// RobotSender 通过自定义机器人 webhook 发送到群，适合团队共享日程
+type RobotSender struct {
+	Webhook *robot.Webhook
+}
+
+func (s *RobotSender) Send(ctx context.Context, d *Digest) error {
+	return s.Webhook.SendMarkdown(ctx, d.Title, d.Text, nil)
+}
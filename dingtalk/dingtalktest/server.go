@@ -0,0 +1,463 @@
+// Package dingtalktest 提供模拟钉钉开放平台的测试服务器，配合 dingtalk.WithBaseURL 使用，
+// 使依赖钉钉接口的测试不需要访问外网
+package dingtalktest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk"
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+const (
+	DefaultClientID     = "dingtalktest-client-id"
+	DefaultClientSecret = "dingtalktest-client-secret"
+
+	tokenExpiresIn = 7200
+)
+
+// Fault 注入的故障类型
+type Fault int
+
+const (
+	// FaultExpiredToken 返回 access_token 过期，oapi 接口为 errcode 42001，v1.0 接口为 401
+	FaultExpiredToken Fault = iota + 1
+	// FaultThrottle 返回限流，oapi 接口为 errcode 90018，v1.0 接口为 429
+	FaultThrottle
+	// FaultServerError 返回 HTTP 500
+	FaultServerError
+)
+
+type injectedFault struct {
+	fault Fault
+	times int
+}
+
+// Server 模拟钉钉开放平台的 HTTP 服务器，oapi.dingtalk.com 和 api.dingtalk.com 的接口使用同一个地址
+type Server struct {
+	*httptest.Server
+	Store *Store
+
+	ClientID     string
+	ClientSecret string
+	// EventPageSize 日程列表每页返回的数量
+	EventPageSize int
+
+	mutex    sync.Mutex
+	faults   map[string]*injectedFault
+	requests map[string]int
+}
+
+// NewServer 启动模拟服务器，使用完毕后需要调用 Close
+func NewServer() *Server {
+	s := &Server{
+		Store:         newStore(),
+		ClientID:      DefaultClientID,
+		ClientSecret:  DefaultClientSecret,
+		EventPageSize: 100,
+		faults:        map[string]*injectedFault{},
+		requests:      map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Client 返回指向模拟服务器的客户端，opts 在默认配置之后生效
+func (s *Server) Client(opts ...dingtalk.Option) *dingtalk.Client {
+	defaults := []dingtalk.Option{
+		dingtalk.WithBaseURL(s.URL, s.URL),
+		dingtalk.WithRetry(2, time.Millisecond),
+	}
+	return dingtalk.NewDingTalkClient(s.ClientID, s.ClientSecret, append(defaults, opts...)...)
+}
+
+// InjectFault 使接下来 times 次对 path 的请求返回 fault，path 为不含 query 的请求路径，如 /topapi/v2/user/get
+func (s *Server) InjectFault(path string, fault Fault, times int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.faults[path] = &injectedFault{fault: fault, times: times}
+}
+
+// Requests 返回 path 收到的请求次数，包括注入故障的请求
+func (s *Server) Requests(path string) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.requests[path]
+}
+
+func (s *Server) takeFault(path string) Fault {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.requests[path]++
+	f, ok := s.faults[path]
+	if !ok {
+		return 0
+	}
+	f.times--
+	if f.times <= 0 {
+		delete(s.faults, path)
+	}
+	return f.fault
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Path
+	oapi := !strings.HasPrefix(path, "/v1.0/")
+	switch s.takeFault(path) {
+	case FaultExpiredToken:
+		if oapi {
+			writeTopError(w, 42001, "access_token expired")
+		} else {
+			writeAPIError(w, http.StatusUnauthorized, "InvalidAuthentication", "access_token expired")
+		}
+		return
+	case FaultThrottle:
+		if oapi {
+			writeTopError(w, 90018, "too many requests")
+		} else {
+			writeAPIError(w, http.StatusTooManyRequests, "Throttling", "too many requests")
+		}
+		return
+	case FaultServerError:
+		writeAPIError(w, http.StatusInternalServerError, "ServiceUnavailable", "injected server error")
+		return
+	}
+
+	switch {
+	case path == "/gettoken":
+		s.getToken(w, r)
+		return
+	case path == "/v1.0/oauth2/userAccessToken":
+		s.userAccessToken(w, r)
+		return
+	}
+
+	var token string
+	if oapi {
+		token = r.URL.Query().Get("access_token")
+	} else {
+		token = r.Header.Get("x-acs-dingtalk-access-token")
+	}
+	tokenUnionId, ok := s.Store.checkToken(token)
+	if !ok {
+		if oapi {
+			writeTopError(w, 40014, "invalid access_token")
+		} else {
+			writeAPIError(w, http.StatusUnauthorized, "InvalidAuthentication", "invalid access_token")
+		}
+		return
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case path == "/topapi/user/getbyunionid" && r.Method == http.MethodPost:
+		s.getByUnionID(w, r)
+	case path == "/topapi/v2/user/get" && r.Method == http.MethodPost:
+		s.getTopUser(w, r)
+	case path == "/topapi/message/corpconversation/asyncsend_v2" && r.Method == http.MethodPost:
+		s.sendWorkNotification(w, r)
+	case matchPath(segments, "v1.0", "contact", "users", "*") && r.Method == http.MethodGet:
+		s.getContactUser(w, resolveMe(segments[3], tokenUnionId))
+	case matchPath(segments, "v1.0", "calendar", "users", "*", "calendars") && r.Method == http.MethodGet:
+		s.listCalendars(w)
+	case matchPath(segments, "v1.0", "calendar", "users", "*", "calendars", "*", "events") && r.Method == http.MethodGet:
+		s.listEvents(w, r, resolveMe(segments[3], tokenUnionId))
+	case matchPath(segments, "v1.0", "calendar", "users", "*", "calendars", "*", "events") && r.Method == http.MethodPost:
+		s.createEvent(w, r, resolveMe(segments[3], tokenUnionId))
+	case matchPath(segments, "v1.0", "todo", "users", "*", "tasks") && r.Method == http.MethodPost:
+		s.createTodo(w, r, segments[3])
+	case matchPath(segments, "v1.0", "todo", "users", "*", "tasks", "sources", "*") && r.Method == http.MethodGet:
+		s.getTodoBySource(w, segments[3], segments[6])
+	case matchPath(segments, "v1.0", "todo", "users", "*", "org", "tasks", "query") && r.Method == http.MethodPost:
+		s.queryTodos(w, r, segments[3])
+	default:
+		if oapi {
+			writeTopError(w, 404, "api not found")
+		} else {
+			writeAPIError(w, http.StatusNotFound, "ApiNotFound", "api not found: "+r.Method+" "+path)
+		}
+	}
+}
+
+func (s *Server) getToken(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("appkey") != s.ClientID || query.Get("appsecret") != s.ClientSecret {
+		writeTopError(w, 40089, "invalid appkey or appsecret")
+		return
+	}
+	writeJSON(w, http.StatusOK, models.GetTokenResponse{AccessToken: s.Store.issueAppToken(), ExpiresIn: tokenExpiresIn})
+}
+
+func (s *Server) userAccessToken(w http.ResponseWriter, r *http.Request) {
+	req := models.UserAccessTokenRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "InvalidParameter", err.Error())
+		return
+	}
+	if req.ClientID != s.ClientID || req.ClientSecret != s.ClientSecret {
+		writeAPIError(w, http.StatusBadRequest, "InvalidAuthentication.ClientSecret", "invalid clientId or clientSecret")
+		return
+	}
+	token, ok := s.Store.exchangeAuthCode(req.Code)
+	if !ok {
+		writeAPIError(w, http.StatusBadRequest, "InvalidParameter.Code", "invalid or used auth code")
+		return
+	}
+	writeJSON(w, http.StatusOK, models.UserAccessTokenResponse{AccessToken: token, ExpireIn: tokenExpiresIn})
+}
+
+func (s *Server) getByUnionID(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	_ = json.NewDecoder(r.Body).Decode(&params)
+	user, ok := s.Store.findUser(func(u *User) bool { return u.UnionID == params["unionid"] })
+	if !ok {
+		writeTopError(w, 60121, "user not found")
+		return
+	}
+	writeTopResult(w, models.TopGetByUnionIdResponse{UserID: user.UserID})
+}
+
+func (s *Server) getTopUser(w http.ResponseWriter, r *http.Request) {
+	params := map[string]string{}
+	_ = json.NewDecoder(r.Body).Decode(&params)
+	user, ok := s.Store.findUser(func(u *User) bool { return u.UserID == params["userid"] })
+	if !ok {
+		writeTopError(w, 60121, "user not found")
+		return
+	}
+	writeTopResult(w, models.TopUser{
+		Userid:  user.UserID,
+		UnionID: user.UnionID,
+		Name:    user.Name,
+		Mobile:  user.Mobile,
+		Email:   user.Email,
+		Avatar:  user.Avatar,
+		Active:  true,
+	})
+}
+
+func (s *Server) sendWorkNotification(w http.ResponseWriter, r *http.Request) {
+	req := models.SendWorkNotificationRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.AgentID == 0 {
+		writeTopError(w, 40003, "invalid agent_id")
+		return
+	}
+	taskId := s.Store.addWorkNotification(req)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"errcode": 0, "errmsg": "ok", "task_id": taskId})
+}
+
+func (s *Server) getContactUser(w http.ResponseWriter, unionId string) {
+	user, ok := s.Store.findUser(func(u *User) bool { return u.UnionID == unionId })
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "UserNotExist", "user not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, models.ContactUser{
+		Nick:      user.Name,
+		AvatarUrl: user.Avatar,
+		Mobile:    user.Mobile,
+		UnionID:   user.UnionID,
+		Email:     user.Email,
+	})
+}
+
+func (s *Server) listCalendars(w http.ResponseWriter) {
+	primary := &models.Calendar{ID: dingtalk.PrimaryCalendarID, Summary: "primary", TimeZone: "Asia/Shanghai", Type: "primary"}
+	writeJSON(w, http.StatusOK, models.CalendarResponse{
+		CalendarOriginResponse: &models.CalendarOriginResponse{Calendars: models.CalendarList{primary}},
+	})
+}
+
+// listEvents 按开始时间过滤 [timeMin, timeMax] 内的日程，nextToken 为下一页的偏移量
+func (s *Server) listEvents(w http.ResponseWriter, r *http.Request, unionId string) {
+	query := r.URL.Query()
+	timeMin, _ := time.Parse(time.RFC3339, query.Get("timeMin"))
+	timeMax, _ := time.Parse(time.RFC3339, query.Get("timeMax"))
+	events := models.CalendarEventList{}
+	for _, event := range s.Store.Events(unionId) {
+		start := event.Start.DateTime.Time
+		if start.IsZero() {
+			start = event.Start.Date.Time
+		}
+		if !start.IsZero() && ((!timeMin.IsZero() && start.Before(timeMin)) || (!timeMax.IsZero() && start.After(timeMax))) {
+			continue
+		}
+		e := event
+		events = append(events, &e)
+	}
+	offset, _ := strconv.Atoi(query.Get("nextToken"))
+	if offset > len(events) {
+		offset = len(events)
+	}
+	end := offset + s.EventPageSize
+	resp := models.EventResponse{Events: models.CalendarEventList{}}
+	if end < len(events) {
+		resp.NextToken = strconv.Itoa(end)
+	} else {
+		end = len(events)
+	}
+	resp.Events = append(resp.Events, events[offset:end]...)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) createEvent(w http.ResponseWriter, r *http.Request, unionId string) {
+	req := models.CreateCalendarEventRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Summary == "" {
+		writeAPIError(w, http.StatusBadRequest, "InvalidParameter.Summary", "summary is required")
+		return
+	}
+	event := models.CalendarEvent{
+		Summary:     req.Summary,
+		Description: req.Description,
+		Start:       eventTime(req.Start),
+		End:         eventTime(req.End),
+		IsAllDay:    req.IsAllDay,
+		Organizer:   models.EventOrganizer{Id: unionId, ResponseStatus: "accepted"},
+		CreateTime:  models.Time{Time: time.Now()},
+		UpdateTime:  models.Time{Time: time.Now()},
+		Status:      "confirmed",
+	}
+	for _, attendee := range req.Attendees {
+		event.Attendees = append(event.Attendees, models.EventAttendee{
+			Id:             attendee.ID,
+			ResponseStatus: "needsAction",
+			IsOptional:     attendee.IsOptional,
+		})
+	}
+	if req.Location != nil {
+		event.Location.DisplayName = req.Location.DisplayName
+	}
+	event.ID = s.Store.AddEvent(unionId, event)
+	if req.OnlineMeetingInfo != nil {
+		event.OnlineMeetingInfo = models.EventOnlineMeetingInfo{
+			Type:         req.OnlineMeetingInfo.Type,
+			ConferenceId: "conf-" + event.ID,
+			Url:          s.URL + "/meeting/" + event.ID,
+		}
+		s.Store.updateEvent(unionId, event)
+	}
+	writeJSON(w, http.StatusOK, event)
+}
+
+func (s *Server) createTodo(w http.ResponseWriter, r *http.Request, unionId string) {
+	req := models.CreateTodoTaskRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Subject == "" {
+		writeAPIError(w, http.StatusBadRequest, "InvalidParameter.Subject", "subject is required")
+		return
+	}
+	if r.URL.Query().Get("operatorId") != unionId {
+		writeAPIError(w, http.StatusForbidden, "Forbidden.OperatorId", "operatorId mismatch")
+		return
+	}
+	todo, ok := s.Store.addTodo(unionId, req)
+	if !ok {
+		writeAPIError(w, http.StatusBadRequest, "InvalidParameter.SourceId", "sourceId already exists")
+		return
+	}
+	now := models.EpochMillis{Time: time.Now()}
+	writeJSON(w, http.StatusOK, models.CreateTodoTaskResponse{
+		ID:             todo.ID,
+		CreatedTime:    now,
+		ModifiedTime:   now,
+		CreatorID:      req.CreatorID,
+		DueTime:        models.EpochMillis{Time: time.UnixMilli(req.DueTime)},
+		ParticipantIds: req.ParticipantIds,
+		Subject:        req.Subject,
+		Source:         req.SourceID,
+	})
+}
+
+func (s *Server) getTodoBySource(w http.ResponseWriter, unionId, sourceId string) {
+	todo, ok := s.Store.findTodo(unionId, func(t *Todo) bool { return t.SourceID == sourceId })
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "taskNotExist", "todo not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, models.TodoTask{
+		ID:             todo.ID,
+		Subject:        todo.Subject,
+		Description:    todo.Description,
+		SourceID:       todo.SourceID,
+		CreatorID:      todo.CreatorID,
+		ExecutorIds:    todo.ExecutorIds,
+		ParticipantIds: todo.ParticipantIds,
+		DueTime:        models.EpochMillis{Time: time.UnixMilli(todo.DueTime)},
+		DetailUrl:      todo.DetailUrl,
+	})
+}
+
+// queryTodos 返回 unionId 创建的待办，模拟服务器中的待办都是未完成状态
+func (s *Server) queryTodos(w http.ResponseWriter, r *http.Request, unionId string) {
+	req := models.QueryTodoTasksRequest{}
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	resp := models.QueryTodoTasksResponse{TodoCards: []models.TodoCard{}}
+	if !req.IsDone {
+		for _, todo := range s.Store.Todos(unionId) {
+			resp.TodoCards = append(resp.TodoCards, models.TodoCard{
+				TaskID:    todo.ID,
+				Subject:   todo.Subject,
+				SourceID:  todo.SourceID,
+				CreatorID: todo.CreatorID,
+				DueTime:   models.EpochMillis{Time: time.UnixMilli(todo.DueTime)},
+				DetailUrl: models.TodoCardDetailUrl{AppUrl: todo.DetailUrl.AppUrl, PcUrl: todo.DetailUrl.PcUrl},
+			})
+		}
+	}
+	resp.TotalCount = models.FlexInt(len(resp.TodoCards))
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func eventTime(t models.CreateEventTime) models.EventTime {
+	result := models.EventTime{TimeZone: t.TimeZone}
+	if t.DateTime != "" {
+		result.DateTime.Time, _ = time.Parse(time.RFC3339, t.DateTime)
+	}
+	if t.Date != "" {
+		result.Date.Time, _ = time.ParseInLocation("2006-01-02", t.Date, models.DefaultLocation)
+	}
+	return result
+}
+
+// matchPath 匹配路径分段，* 匹配任意一段
+func matchPath(segments []string, pattern ...string) bool {
+	if len(segments) != len(pattern) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != "*" && p != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func resolveMe(unionId, tokenUnionId string) string {
+	if unionId == "me" {
+		return tokenUnionId
+	}
+	return unionId
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeTopResult(w http.ResponseWriter, result interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"errcode": 0, "errmsg": "ok", "result": result})
+}
+
+func writeTopError(w http.ResponseWriter, errCode int, errMsg string) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"errcode": errCode, "errmsg": errMsg})
+}
+
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"code": code, "message": message, "requestid": "dingtalktest"})
+}
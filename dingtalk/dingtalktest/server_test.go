@@ -0,0 +1,135 @@
+package dingtalktest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk"
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/chzealot/gobase/logger"
+)
+
+func newServer(t *testing.T) *Server {
+	_ = logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff})
+	s := NewServer()
+	t.Cleanup(s.Close)
+	s.Store.AddUser(User{UserID: "user1", UnionID: "union1", Name: "Alice", Mobile: "13800000000"})
+	return s
+}
+
+func TestUserFlows(t *testing.T) {
+	s := newServer(t)
+	client := s.Client()
+
+	userId, err := client.GetUserIDByUnionID("union1")
+	if err != nil || userId != "user1" {
+		t.Fatalf("GetUserIDByUnionID: userId=%s, err=%v", userId, err)
+	}
+	user, err := client.GetUserFromTop(userId)
+	if err != nil || user.Name != "Alice" || user.UnionID != "union1" {
+		t.Fatalf("GetUserFromTop: user=%+v, err=%v", user, err)
+	}
+	if _, err := client.GetUserIDByUnionID("nobody"); !dingtalk.IsNotFound(err) {
+		t.Errorf("unknown unionId: err=%v", err)
+	}
+
+	s.Store.AddAuthCode("code1", "union1")
+	token, err := client.GetUserAccessToken("code1")
+	if err != nil {
+		t.Fatalf("GetUserAccessToken: %v", err)
+	}
+	unionId, err := client.GetMyUnionID(token.AccessToken)
+	if err != nil || unionId != "union1" {
+		t.Fatalf("GetMyUnionID: unionId=%s, err=%v", unionId, err)
+	}
+	if _, err := client.GetUserAccessToken("code1"); !dingtalk.IsInvalidParameter(err) {
+		t.Errorf("reused auth code: err=%v", err)
+	}
+}
+
+func TestCalendarAndTodo(t *testing.T) {
+	s := newServer(t)
+	s.EventPageSize = 1
+	client := s.Client()
+	ctx := context.Background()
+	start := time.Now().Add(time.Hour)
+
+	created, err := client.CreateCalendarEvent(ctx, "union1", &models.CreateCalendarEventRequest{
+		Summary: "weekly",
+		Start:   dingtalk.EventDateTime(start),
+		End:     dingtalk.EventDateTime(start.Add(time.Hour)),
+	}, dingtalk.WithOnlineMeeting())
+	if err != nil || created.ID == "" || created.OnlineMeetingInfo.Url == "" {
+		t.Fatalf("CreateCalendarEvent: event=%+v, err=%v", created, err)
+	}
+	s.Store.AddEvent("union1", models.CalendarEvent{Summary: "seeded", Start: models.EventTime{DateTime: models.Time{Time: start}}})
+	s.Store.AddEvent("union1", models.CalendarEvent{Summary: "last year", Start: models.EventTime{DateTime: models.Time{Time: start.AddDate(-1, 0, 0)}}})
+
+	token := s.Store.IssueUserToken("union1")
+	timeMin := start.Add(-time.Minute).Format(time.RFC3339)
+	timeMax := start.Add(time.Minute).Format(time.RFC3339)
+	events, err := client.IterateCalendarEvents(token, "union1", dingtalk.PrimaryCalendarID, timeMin, timeMax).All(ctx)
+	if err != nil || len(events) != 2 || events[0].Summary != "weekly" || events[1].Summary != "seeded" {
+		t.Fatalf("IterateCalendarEvents: events=%v, err=%v", events, err)
+	}
+
+	todo, err := client.CreateTodoTask("union1", "review", start)
+	if err != nil || todo.ID == "" {
+		t.Fatalf("CreateTodoTask: todo=%+v, err=%v", todo, err)
+	}
+	if todos := s.Store.Todos("union1"); len(todos) != 1 || todos[0].Subject != "review" || todos[0].ID != todo.ID {
+		t.Errorf("stored todos = %+v", todos)
+	}
+	cards, err := client.ListTodoTasks(ctx, "union1", false)
+	if err != nil || len(cards) != 1 || cards[0].TaskID != todo.ID {
+		t.Errorf("ListTodoTasks: cards=%+v, err=%v", cards, err)
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	s := newServer(t)
+	client := s.Client()
+
+	s.InjectFault("/topapi/v2/user/get", FaultThrottle, 1)
+	s.InjectFault("/topapi/user/getbyunionid", FaultServerError, 1)
+	if _, err := client.GetUserFromTop("user1"); err != nil {
+		t.Fatalf("throttled once: %v", err)
+	}
+	if n := s.Requests("/topapi/v2/user/get"); n != 2 {
+		t.Errorf("user/get requests = %d, want 2", n)
+	}
+	// 服务端错误时 POST 可能已经被处理，不重试
+	if _, err := client.GetUserIDByUnionID("union1"); dingtalk.ErrorKindOf(err) != dingtalk.ErrorKindServer {
+		t.Errorf("server error on POST: err=%v", err)
+	}
+	if n := s.Requests("/topapi/user/getbyunionid"); n != 1 {
+		t.Errorf("getbyunionid requests = %d, want 1", n)
+	}
+	if _, err := client.GetUserIDByUnionID("union1"); err != nil {
+		t.Fatalf("after server error: %v", err)
+	}
+
+	// GET 在服务端错误时重试
+	token := s.Store.IssueUserToken("union1")
+	s.InjectFault("/v1.0/contact/users/me", FaultServerError, 2)
+	if _, err := client.GetContactUser(token, "me"); err != nil {
+		t.Fatalf("server error twice on GET: %v", err)
+	}
+	s.InjectFault("/v1.0/contact/users/me", FaultServerError, 3)
+	if _, err := client.GetContactUser(token, "me"); dingtalk.ErrorKindOf(err) != dingtalk.ErrorKindServer {
+		t.Errorf("retries exhausted: err=%v", err)
+	}
+
+	s.Store.ExpireTokens()
+	if _, err := client.GetUserFromTop("user1"); err != nil {
+		t.Fatalf("expired tokens: %v", err)
+	}
+	s.InjectFault("/topapi/v2/user/get", FaultExpiredToken, 1)
+	if _, err := client.GetUserFromTop("user1"); err != nil {
+		t.Fatalf("expired token fault: %v", err)
+	}
+	if n := s.Requests("/gettoken"); n != 3 {
+		t.Errorf("gettoken requests = %d, want 3", n)
+	}
+}
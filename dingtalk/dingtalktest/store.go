@@ -0,0 +1,224 @@
+package dingtalktest
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+// User 模拟服务器中的用户，UserID 用于 topapi 接口，UnionID 用于 v1.0 接口
+type User struct {
+	UserID  string
+	UnionID string
+	Name    string
+	Mobile  string
+	Email   string
+	Avatar  string
+}
+
+// WorkNotification 通过 SendWorkNotification 发送的工作通知
+type WorkNotification struct {
+	TaskID int64
+	models.SendWorkNotificationRequest
+}
+
+// Todo 通过 CreateTodoTask 创建的待办
+type Todo struct {
+	ID string
+	models.CreateTodoTaskRequest
+}
+
+// Store 模拟服务器的内存数据，可在测试开始前预置用户、日程和授权码，并发安全
+type Store struct {
+	mutex      sync.Mutex
+	users      []*User
+	events     map[string][]*models.CalendarEvent
+	todos      map[string][]*Todo
+	messages   []WorkNotification
+	authCodes  map[string]string
+	appTokens  map[string]bool
+	userTokens map[string]string
+	seq        int
+}
+
+func newStore() *Store {
+	return &Store{
+		events:     map[string][]*models.CalendarEvent{},
+		todos:      map[string][]*Todo{},
+		authCodes:  map[string]string{},
+		appTokens:  map[string]bool{},
+		userTokens: map[string]string{},
+	}
+}
+
+// AddUser 添加用户，UserID 或 UnionID 已存在时覆盖
+func (s *Store) AddUser(user User) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, u := range s.users {
+		if u.UserID == user.UserID || u.UnionID == user.UnionID {
+			s.users[i] = &user
+			return
+		}
+	}
+	s.users = append(s.users, &user)
+}
+
+// AddEvent 在 unionId 的主日历中添加日程，未设置 ID 时自动生成，返回日程 ID
+func (s *Store) AddEvent(unionId string, event models.CalendarEvent) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if event.ID == "" {
+		event.ID = s.nextID("event")
+	}
+	s.events[unionId] = append(s.events[unionId], &event)
+	return event.ID
+}
+
+func (s *Store) updateEvent(unionId string, event models.CalendarEvent) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for i, e := range s.events[unionId] {
+		if e.ID == event.ID {
+			s.events[unionId][i] = &event
+			return
+		}
+	}
+}
+
+// Events 返回 unionId 主日历中的所有日程
+func (s *Store) Events(unionId string) []models.CalendarEvent {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	events := make([]models.CalendarEvent, 0, len(s.events[unionId]))
+	for _, event := range s.events[unionId] {
+		events = append(events, *event)
+	}
+	return events
+}
+
+// AddAuthCode 添加 unionId 的 OAuth 授权码，用于 GetUserAccessToken，授权码只能使用一次
+func (s *Store) AddAuthCode(code, unionId string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.authCodes[code] = unionId
+}
+
+// IssueUserToken 直接为 unionId 签发用户 access_token，跳过 OAuth 授权流程
+func (s *Store) IssueUserToken(unionId string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	token := s.nextID("user-token")
+	s.userTokens[token] = unionId
+	return token
+}
+
+// Todos 返回 unionId 创建的待办
+func (s *Store) Todos(unionId string) []Todo {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	todos := make([]Todo, 0, len(s.todos[unionId]))
+	for _, todo := range s.todos[unionId] {
+		todos = append(todos, *todo)
+	}
+	return todos
+}
+
+// WorkNotifications 返回已发送的工作通知
+func (s *Store) WorkNotifications() []WorkNotification {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]WorkNotification(nil), s.messages...)
+}
+
+func (s *Store) addWorkNotification(req models.SendWorkNotificationRequest) int64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.seq++
+	msg := WorkNotification{TaskID: int64(s.seq), SendWorkNotificationRequest: req}
+	s.messages = append(s.messages, msg)
+	return msg.TaskID
+}
+
+// ExpireTokens 使已签发的应用和用户 access_token 全部失效
+func (s *Store) ExpireTokens() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.appTokens = map[string]bool{}
+	s.userTokens = map[string]string{}
+}
+
+func (s *Store) issueAppToken() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	token := s.nextID("app-token")
+	s.appTokens[token] = true
+	return token
+}
+
+func (s *Store) exchangeAuthCode(code string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	unionId, ok := s.authCodes[code]
+	if !ok {
+		return "", false
+	}
+	delete(s.authCodes, code)
+	token := s.nextID("user-token")
+	s.userTokens[token] = unionId
+	return token, true
+}
+
+// checkToken 校验 access_token，用户 token 返回对应的 unionId
+func (s *Store) checkToken(token string) (unionId string, ok bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.appTokens[token] {
+		return "", true
+	}
+	unionId, ok = s.userTokens[token]
+	return unionId, ok
+}
+
+func (s *Store) findUser(match func(*User) bool) (User, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, u := range s.users {
+		if match(u) {
+			return *u, true
+		}
+	}
+	return User{}, false
+}
+
+// addTodo 创建待办，同一用户的 SourceID 重复时返回 false
+func (s *Store) addTodo(unionId string, req models.CreateTodoTaskRequest) (Todo, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, todo := range s.todos[unionId] {
+		if req.SourceID != "" && todo.SourceID == req.SourceID {
+			return Todo{}, false
+		}
+	}
+	todo := &Todo{ID: s.nextID("todo"), CreateTodoTaskRequest: req}
+	s.todos[unionId] = append(s.todos[unionId], todo)
+	return *todo, true
+}
+
+func (s *Store) findTodo(unionId string, match func(*Todo) bool) (Todo, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, todo := range s.todos[unionId] {
+		if match(todo) {
+			return *todo, true
+		}
+	}
+	return Todo{}, false
+}
+
+// nextID 生成递增的 ID，调用方需持有锁
+func (s *Store) nextID(prefix string) string {
+	s.seq++
+	return prefix + "-" + strconv.Itoa(s.seq)
+}
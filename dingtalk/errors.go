@@ -0,0 +1,157 @@
+package dingtalk
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Error 钉钉接口返回的错误。oapi.dingtalk.com 接口的 Code 为 errcode，
+// api.dingtalk.com 接口的 Code 为响应体中的 code
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+	RequestID  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("dingtalk: status=%d, code=%s, message=%s, requestId=%s",
+		e.StatusCode, e.Code, e.Message, e.RequestID)
+}
+
+func newTopError(statusCode, errCode int, errMsg, requestId string) *Error {
+	return &Error{
+		StatusCode: statusCode,
+		Code:       strconv.Itoa(errCode),
+		Message:    errMsg,
+		RequestID:  requestId,
+	}
+}
+
+// ErrorKind 错误分类，便于调用方区分可重试、需要授权、参数错误等情况
+type ErrorKind int
+
+const (
+	ErrorKindUnknown ErrorKind = iota
+	// ErrorKindAuth access_token 无效或已过期、应用凭证错误
+	ErrorKindAuth
+	// ErrorKindPermission 应用没有接口权限、IP 不在白名单、不在可见范围等
+	ErrorKindPermission
+	// ErrorKindNotFound 用户、群、审批实例等资源不存在
+	ErrorKindNotFound
+	// ErrorKindInvalidParameter 参数错误
+	ErrorKindInvalidParameter
+	// ErrorKindRateLimited 钉钉或客户端限流
+	ErrorKindRateLimited
+	// ErrorKindServer 钉钉服务端错误，可以稍后重试
+	ErrorKindServer
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorKindAuth:
+		return "auth"
+	case ErrorKindPermission:
+		return "permission"
+	case ErrorKindNotFound:
+		return "not_found"
+	case ErrorKindInvalidParameter:
+		return "invalid_parameter"
+	case ErrorKindRateLimited:
+		return "rate_limited"
+	case ErrorKindServer:
+		return "server"
+	}
+	return "unknown"
+}
+
+// oapi.dingtalk.com 接口的 errcode 分类
+// 文档：https://open.dingtalk.com/document/orgapp/server-api-error-codes-1
+var topErrorKinds = map[string]ErrorKind{
+	"40001":   ErrorKindAuth,
+	"40014":   ErrorKindAuth,
+	"42001":   ErrorKindAuth,
+	"40089":   ErrorKindAuth,
+	"60011":   ErrorKindPermission,
+	"60020":   ErrorKindPermission,
+	"60121":   ErrorKindNotFound,
+	"60003":   ErrorKindNotFound,
+	"40003":   ErrorKindInvalidParameter,
+	"40035":   ErrorKindInvalidParameter,
+	"33012":   ErrorKindInvalidParameter,
+	"4000003": ErrorKindNotFound,
+	"90002":   ErrorKindRateLimited,
+	"90018":   ErrorKindRateLimited,
+	"-1":      ErrorKindServer,
+}
+
+// Kind 根据 errcode、code 和 HTTP 状态码对错误分类
+func (e *Error) Kind() ErrorKind {
+	if kind, ok := topErrorKinds[e.Code]; ok {
+		return kind
+	}
+	// api.dingtalk.com 接口的 code 形如 Forbidden.AccessDenied.AccessTokenPermissionDenied
+	switch {
+	case strings.HasPrefix(e.Code, "InvalidAuthentication"):
+		return ErrorKindAuth
+	case strings.HasPrefix(e.Code, "Forbidden"):
+		return ErrorKindPermission
+	case strings.HasPrefix(e.Code, "Throttling"):
+		return ErrorKindRateLimited
+	case strings.HasPrefix(e.Code, "InvalidParameter"), strings.HasPrefix(e.Code, "MissingParameter"),
+		strings.HasPrefix(e.Code, "paramError"):
+		return ErrorKindInvalidParameter
+	case strings.Contains(strings.ToLower(e.Code), "notfound"), strings.Contains(strings.ToLower(e.Code), "notexist"):
+		return ErrorKindNotFound
+	}
+	switch {
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrorKindAuth
+	case e.StatusCode == http.StatusForbidden:
+		return ErrorKindPermission
+	case e.StatusCode == http.StatusNotFound:
+		return ErrorKindNotFound
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrorKindRateLimited
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrorKindInvalidParameter
+	case e.StatusCode >= 500:
+		return ErrorKindServer
+	}
+	return ErrorKindUnknown
+}
+
+// ErrorKindOf 返回 err 的分类，包括客户端限流返回的 ErrRateLimited 和 ErrDailyQuotaExceeded
+func ErrorKindOf(err error) ErrorKind {
+	if errors.Is(err, ErrRateLimited) || errors.Is(err, ErrDailyQuotaExceeded) {
+		return ErrorKindRateLimited
+	}
+	apiErr := &Error{}
+	if errors.As(err, &apiErr) {
+		return apiErr.Kind()
+	}
+	return ErrorKindUnknown
+}
+
+func IsAuthError(err error) bool {
+	return ErrorKindOf(err) == ErrorKindAuth
+}
+
+func IsPermissionDenied(err error) bool {
+	return ErrorKindOf(err) == ErrorKindPermission
+}
+
+func IsNotFound(err error) bool {
+	return ErrorKindOf(err) == ErrorKindNotFound
+}
+
+func IsInvalidParameter(err error) bool {
+	return ErrorKindOf(err) == ErrorKindInvalidParameter
+}
+
+func IsRateLimited(err error) bool {
+	return ErrorKindOf(err) == ErrorKindRateLimited
+}
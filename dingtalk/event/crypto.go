@@ -0,0 +1,150 @@
+package event
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidSignature = errors.New("event: invalid signature")
+	ErrInvalidOwnerKey  = errors.New("event: appKey/corpId mismatch")
+)
+
+// Crypto 钉钉事件订阅的加解密，算法为 AES-256-CBC + PKCS#7，签名为 SHA-1
+// 文档：https://open.dingtalk.com/document/orgapp/configure-event-subcription
+type Crypto struct {
+	token    string
+	aesKey   []byte
+	ownerKey string
+}
+
+// NewCrypto token 和 encodingAESKey 为开发者后台配置的签名 token 和加密 aes_key，
+// ownerKey 为企业内部应用的 appKey，第三方应用为 suiteKey，旧版回调为 corpId
+func NewCrypto(token, encodingAESKey, ownerKey string) (*Crypto, error) {
+	if len(encodingAESKey) != 43 {
+		return nil, fmt.Errorf("event.NewCrypto, aes_key must be 43 characters, got %d", len(encodingAESKey))
+	}
+	aesKey, err := base64.StdEncoding.DecodeString(encodingAESKey + "=")
+	if err != nil {
+		return nil, fmt.Errorf("event.NewCrypto, invalid aes_key: %w", err)
+	}
+	return &Crypto{token: token, aesKey: aesKey, ownerKey: ownerKey}, nil
+}
+
+// Signature 计算 msg_signature
+func (c *Crypto) Signature(timestamp, nonce, encrypt string) string {
+	parts := []string{c.token, timestamp, nonce, encrypt}
+	sort.Strings(parts)
+	sum := sha1.Sum([]byte(strings.Join(parts, "")))
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *Crypto) VerifySignature(signature, timestamp, nonce, encrypt string) bool {
+	expected := c.Signature(timestamp, nonce, encrypt)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(signature)) == 1
+}
+
+// Decrypt 解密 encrypt 字段，并校验明文尾部的 appKey/corpId
+func (c *Crypto) Decrypt(encrypt string) ([]byte, error) {
+	cipherText, err := base64.StdEncoding.DecodeString(encrypt)
+	if err != nil {
+		return nil, fmt.Errorf("event.Decrypt, invalid base64: %w", err)
+	}
+	block, err := aes.NewCipher(c.aesKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(cipherText) == 0 || len(cipherText)%aes.BlockSize != 0 {
+		return nil, errors.New("event.Decrypt, invalid cipher text length")
+	}
+	plain := make([]byte, len(cipherText))
+	cipher.NewCBCDecrypter(block, c.aesKey[:aes.BlockSize]).CryptBlocks(plain, cipherText)
+	plain, err = pkcs7Unpad(plain)
+	if err != nil {
+		return nil, err
+	}
+	// 明文结构：random(16) + msgLen(4) + msg + ownerKey
+	if len(plain) < 20 {
+		return nil, errors.New("event.Decrypt, plain text too short")
+	}
+	msgLen := int(binary.BigEndian.Uint32(plain[16:20]))
+	if msgLen < 0 || 20+msgLen > len(plain) {
+		return nil, errors.New("event.Decrypt, invalid message length")
+	}
+	msg := plain[20 : 20+msgLen]
+	if c.ownerKey != "" && string(plain[20+msgLen:]) != c.ownerKey {
+		return nil, ErrInvalidOwnerKey
+	}
+	return msg, nil
+}
+
+// Encrypt 加密消息，用于回复 success
+func (c *Crypto) Encrypt(msg []byte) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	buf := bytes.NewBuffer(random)
+	lenBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(lenBytes, uint32(len(msg)))
+	buf.Write(lenBytes)
+	buf.Write(msg)
+	buf.WriteString(c.ownerKey)
+
+	block, err := aes.NewCipher(c.aesKey)
+	if err != nil {
+		return "", err
+	}
+	plain := pkcs7Pad(buf.Bytes(), 32)
+	cipherText := make([]byte, len(plain))
+	cipher.NewCBCEncrypter(block, c.aesKey[:aes.BlockSize]).CryptBlocks(cipherText, plain)
+	return base64.StdEncoding.EncodeToString(cipherText), nil
+}
+
+// EncryptedResponse 为回调的加密应答
+type EncryptedResponse struct {
+	MsgSignature string `json:"msg_signature"`
+	TimeStamp    string `json:"timeStamp"`
+	Nonce        string `json:"nonce"`
+	Encrypt      string `json:"encrypt"`
+}
+
+func (c *Crypto) NewResponse(msg []byte, timestamp, nonce string) (*EncryptedResponse, error) {
+	encrypt, err := c.Encrypt(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedResponse{
+		MsgSignature: c.Signature(timestamp, nonce, encrypt),
+		TimeStamp:    timestamp,
+		Nonce:        nonce,
+		Encrypt:      encrypt,
+	}, nil
+}
+
+func pkcs7Pad(data []byte, blockSize int) []byte {
+	padding := blockSize - len(data)%blockSize
+	return append(data, bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
+
+func pkcs7Unpad(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, errors.New("event.Decrypt, empty plain text")
+	}
+	padding := int(data[len(data)-1])
+	if padding < 1 || padding > 32 || padding > len(data) {
+		return nil, errors.New("event.Decrypt, invalid padding")
+	}
+	return data[:len(data)-padding], nil
+}
@@ -0,0 +1,114 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/chzealot/gobase/logger"
+)
+
+const maxBodySize = 1 << 20
+
+// MaxTimestampSkew 回调 timestamp 与本地时间允许的最大偏差，超出时拒绝，防止签名过的回调被重放
+const MaxTimestampSkew = time.Hour
+
+var ErrStaleTimestamp = errors.New("event: stale timestamp")
+
+// checkTimestamp 校验毫秒时间戳与 now 的偏差
+func checkTimestamp(timestamp string, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStaleTimestamp
+	}
+	skew := now.Sub(time.UnixMilli(ts))
+	if skew > MaxTimestampSkew || skew < -MaxTimestampSkew {
+		return ErrStaleTimestamp
+	}
+	return nil
+}
+
+// CallbackHandler 接收钉钉 HTTP 事件回调：校验签名和时间戳、解密后交给 Dispatcher 处理，
+// 处理成功后回复加密的 success，失败时返回 5xx 由钉钉重推
+type CallbackHandler struct {
+	crypto     *Crypto
+	dispatcher Dispatcher
+}
+
+func NewCallbackHandler(crypto *Crypto, dispatcher Dispatcher) *CallbackHandler {
+	return &CallbackHandler{crypto: crypto, dispatcher: dispatcher}
+}
+
+type callbackBody struct {
+	Encrypt string `json:"encrypt"`
+}
+
+func (h *CallbackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	query := r.URL.Query()
+	signature := query.Get("msg_signature")
+	if signature == "" {
+		signature = query.Get("signature")
+	}
+	timestamp := query.Get("timestamp")
+	nonce := query.Get("nonce")
+	if err := checkTimestamp(timestamp, time.Now()); err != nil {
+		logger.WarnwCtx(ctx, "event.CallbackHandler, stale timestamp", "timestamp", timestamp, "nonce", nonce)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	bodyBytes, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	body := &callbackBody{}
+	if err = json.Unmarshal(bodyBytes, body); err != nil || body.Encrypt == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !h.crypto.VerifySignature(signature, timestamp, nonce, body.Encrypt) {
+		logger.WarnwCtx(ctx, "event.CallbackHandler, invalid signature", "timestamp", timestamp, "nonce", nonce)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	plain, err := h.crypto.Decrypt(body.Encrypt)
+	if err != nil {
+		logger.WarnwCtx(ctx, "event.CallbackHandler, decrypt failed", "error", err)
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrInvalidOwnerKey) {
+			status = http.StatusForbidden
+		}
+		w.WriteHeader(status)
+		return
+	}
+	e, err := ParseEvent(plain)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if e.Type != EventTypeCheckURL {
+		if err = h.dispatcher.Dispatch(ctx, e); err != nil {
+			logger.ErrorwCtx(ctx, "event.CallbackHandler, dispatch failed",
+				"eventType", e.Type, "eventId", e.ID, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	resp, err := h.crypto.NewResponse([]byte("success"), timestamp, nonce)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
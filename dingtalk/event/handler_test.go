@@ -0,0 +1,203 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/chzealot/gobase/logger"
+)
+
+const (
+	testToken  = "test-token"
+	testAESKey = "4g5j64qlyl3zvetqxz5jiocdr586fn2zvjpa8zls3ij"
+	testAppKey = "dingtestappkey"
+)
+
+func newTestCrypto(t *testing.T, ownerKey string) *Crypto {
+	c, err := NewCrypto(testToken, testAESKey, ownerKey)
+	if err != nil {
+		t.Fatalf("NewCrypto() error = %v", err)
+	}
+	return c
+}
+
+func TestCryptoRoundTrip(t *testing.T) {
+	c := newTestCrypto(t, testAppKey)
+	for _, msg := range []string{"success", "", `{"EventType":"user_add_org","UserId":["u1"]}`} {
+		encrypt, err := c.Encrypt([]byte(msg))
+		if err != nil {
+			t.Fatalf("Encrypt() error = %v", err)
+		}
+		plain, err := c.Decrypt(encrypt)
+		if err != nil {
+			t.Fatalf("Decrypt() error = %v", err)
+		}
+		if string(plain) != msg {
+			t.Errorf("Decrypt() = %q, want %q", plain, msg)
+		}
+	}
+
+	encrypt, _ := c.Encrypt([]byte("success"))
+	other := newTestCrypto(t, "another-app")
+	if _, err := other.Decrypt(encrypt); !errors.Is(err, ErrInvalidOwnerKey) {
+		t.Errorf("Decrypt() with wrong appKey error = %v, want ErrInvalidOwnerKey", err)
+	}
+
+	if _, err := NewCrypto(testToken, "short", testAppKey); err == nil {
+		t.Error("NewCrypto() with short aes_key should fail")
+	}
+}
+
+func TestCallbackHandler(t *testing.T) {
+	if err := logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff}); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	c := newTestCrypto(t, testAppKey)
+
+	type userAddOrg struct {
+		UserID []string `json:"UserId"`
+	}
+	var received []string
+	router := NewRouter()
+	On(router, "user_add_org", func(ctx context.Context, e *Event, payload *userAddOrg) error {
+		received = append(received, payload.UserID...)
+		return nil
+	})
+	router.Handle("org_dept_create", func(ctx context.Context, e *Event) error {
+		return errors.New("db down")
+	})
+	handler := NewCallbackHandler(c, router)
+
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	postAt := func(timestamp, plain string, tamper bool) *httptest.ResponseRecorder {
+		encrypt, err := c.Encrypt([]byte(plain))
+		if err != nil {
+			t.Fatalf("Encrypt() error = %v", err)
+		}
+		signature := c.Signature(timestamp, "nonce1", encrypt)
+		if tamper {
+			// 修改第一个字符，签名本身以 0 开头时改为 1
+			if signature[0] == '0' {
+				signature = "1" + signature[1:]
+			} else {
+				signature = "0" + signature[1:]
+			}
+		}
+		query := url.Values{}
+		query.Set("msg_signature", signature)
+		query.Set("timestamp", timestamp)
+		query.Set("nonce", "nonce1")
+		body, _ := json.Marshal(map[string]string{"encrypt": encrypt})
+		r := httptest.NewRequest(http.MethodPost, "/callback?"+query.Encode(), bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	post := func(plain string, tamper bool) *httptest.ResponseRecorder {
+		return postAt(now, plain, tamper)
+	}
+
+	w := post(`{"EventType":"user_add_org","UserId":["u1","u2"],"CorpId":"ding1"}`, false)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", w.Code)
+	}
+	resp := &EncryptedResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if !c.VerifySignature(resp.MsgSignature, resp.TimeStamp, resp.Nonce, resp.Encrypt) {
+		t.Error("response signature does not verify")
+	}
+	if plain, err := c.Decrypt(resp.Encrypt); err != nil || string(plain) != "success" {
+		t.Errorf("response plain = %q, %v, want success", plain, err)
+	}
+	if len(received) != 2 || received[0] != "u1" {
+		t.Errorf("received = %v", received)
+	}
+
+	if w := post(`{"EventType":"check_url"}`, false); w.Code != http.StatusOK {
+		t.Errorf("check_url status = %d, want 200", w.Code)
+	}
+	if w := post(`{"EventType":"user_add_org"}`, true); w.Code != http.StatusForbidden {
+		t.Errorf("tampered signature status = %d, want 403", w.Code)
+	}
+	if w := post(`{"EventType":"org_dept_create"}`, false); w.Code != http.StatusInternalServerError {
+		t.Errorf("failed handler status = %d, want 500", w.Code)
+	}
+
+	// 签名正确但时间戳超出允许偏差的回调被拒绝，不能重放
+	received = nil
+	for _, timestamp := range []string{
+		"1700000000000",
+		strconv.FormatInt(time.Now().Add(2*time.Hour).UnixMilli(), 10),
+		"invalid",
+	} {
+		if w := postAt(timestamp, `{"EventType":"user_add_org","UserId":["u3"]}`, false); w.Code != http.StatusForbidden {
+			t.Errorf("timestamp %s status = %d, want 403", timestamp, w.Code)
+		}
+	}
+	if len(received) != 0 {
+		t.Errorf("stale callbacks dispatched: %v", received)
+	}
+}
+
+func TestRouterMiddleware(t *testing.T) {
+	if err := logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff}); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	router := NewRouter()
+	router.Use(Logging(), Recover(), Dedup(NewMemoryDedupStore(), time.Hour))
+
+	calls := 0
+	router.OnUserLeaveOrg(func(ctx context.Context, e *Event, payload *UserChange) error {
+		calls++
+		if logger.GetTraceID(ctx) != e.ID {
+			t.Errorf("trace id = %q, want %q", logger.GetTraceID(ctx), e.ID)
+		}
+		if calls == 1 {
+			return errors.New("temporary failure")
+		}
+		return nil
+	})
+	panics := 0
+	router.OnOrgDeptRemove(func(ctx context.Context, e *Event, payload *DeptChange) error {
+		panics++
+		if panics == 1 {
+			panic("boom")
+		}
+		return nil
+	})
+
+	e, err := ParseEvent([]byte(`{"EventType":"user_leave_org","UserId":["u1"],"CorpId":"ding1"}`))
+	if err != nil {
+		t.Fatalf("ParseEvent() error = %v", err)
+	}
+	ctx := context.Background()
+	if err = router.Dispatch(ctx, e); err == nil {
+		t.Error("first dispatch should fail")
+	}
+	// 失败后释放去重记录，重推的事件会再次处理
+	if err = router.Dispatch(ctx, e); err != nil {
+		t.Errorf("retry dispatch error = %v", err)
+	}
+	if err = router.Dispatch(ctx, e); err != nil || calls != 2 {
+		t.Errorf("duplicated dispatch error = %v, calls = %d, want 2", err, calls)
+	}
+
+	e, _ = ParseEvent([]byte(`{"EventType":"org_dept_remove","DeptId":[1]}`))
+	if err = router.Dispatch(ctx, e); err == nil {
+		t.Error("panic should be converted to error")
+	}
+	// Recover 在 Dedup 外层，panic 时 Dedup 同样释放去重记录
+	if err = router.Dispatch(ctx, e); err != nil || panics != 2 {
+		t.Errorf("dispatch after panic error = %v, handler calls = %d, want 2", err, panics)
+	}
+}
@@ -0,0 +1,132 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/chzealot/gobase/logger"
+)
+
+// Middleware 包装事件处理函数，按 Use 的顺序由外到内执行
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Logging 记录每个事件的处理结果和耗时，ctx 中没有 trace_id 时使用事件 ID
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, e *Event) error {
+			if logger.GetTraceID(ctx) == "" {
+				ctx = logger.WithTraceID(ctx, e.ID)
+			}
+			start := time.Now()
+			err := next(ctx, e)
+			elapsed := time.Since(start).Milliseconds()
+			if err != nil {
+				logger.ErrorwCtx(ctx, "event handled with error",
+					"eventType", e.Type, "eventId", e.ID, "corpId", e.CorpID,
+					"elapsed_ms", elapsed, "error", err)
+			} else {
+				logger.InfowCtx(ctx, "event handled",
+					"eventType", e.Type, "eventId", e.ID, "corpId", e.CorpID,
+					"elapsed_ms", elapsed)
+			}
+			return err
+		}
+	}
+}
+
+// Recover 将处理函数中的 panic 转换为 error，钉钉会在稍后重推该事件
+func Recover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, e *Event) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.ErrorwCtx(ctx, "event handler panic",
+						"eventType", e.Type, "eventId", e.ID,
+						"panic", r, "stack", string(debug.Stack()))
+					err = fmt.Errorf("event: handler panic: %v", r)
+				}
+			}()
+			return next(ctx, e)
+		}
+	}
+}
+
+// DedupStore 记录已处理的事件 ID，多实例部署时可使用 Redis 等共享存储实现
+type DedupStore interface {
+	// Acquire 事件 ID 首次出现时返回 true
+	Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error)
+	// Release 处理失败时释放，以便重推的事件能再次处理
+	Release(ctx context.Context, id string) error
+}
+
+// Dedup 按事件 ID 去重，ttl 应覆盖钉钉的重推周期。处理失败或 panic 时释放事件 ID，
+// panic 会继续向外抛出，不依赖 Recover 的位置
+func Dedup(store DedupStore, ttl time.Duration) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx context.Context, e *Event) (err error) {
+			if e.ID == "" {
+				return next(ctx, e)
+			}
+			ok, err := store.Acquire(ctx, e.ID, ttl)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				logger.InfowCtx(ctx, "event duplicated, skipped", "eventType", e.Type, "eventId", e.ID)
+				return nil
+			}
+			handled := false
+			defer func() {
+				if handled && err == nil {
+					return
+				}
+				if releaseErr := store.Release(ctx, e.ID); releaseErr != nil {
+					logger.WarnwCtx(ctx, "event dedup release failed", "eventId", e.ID, "error", releaseErr)
+				}
+			}()
+			err = next(ctx, e)
+			handled = true
+			return err
+		}
+	}
+}
+
+// MemoryDedupStore 进程内的 DedupStore，仅适用于单实例
+type MemoryDedupStore struct {
+	mutex     sync.Mutex
+	expireAt  map[string]time.Time
+	lastSweep time.Time
+}
+
+func NewMemoryDedupStore() *MemoryDedupStore {
+	return &MemoryDedupStore{expireAt: make(map[string]time.Time)}
+}
+
+func (s *MemoryDedupStore) Acquire(ctx context.Context, id string, ttl time.Duration) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for key, expireAt := range s.expireAt {
+			if now.After(expireAt) {
+				delete(s.expireAt, key)
+			}
+		}
+		s.lastSweep = now
+	}
+	if expireAt, ok := s.expireAt[id]; ok && now.Before(expireAt) {
+		return false, nil
+	}
+	s.expireAt[id] = now.Add(ttl)
+	return true, nil
+}
+
+func (s *MemoryDedupStore) Release(ctx context.Context, id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.expireAt, id)
+	return nil
+}
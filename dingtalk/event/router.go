@@ -0,0 +1,117 @@
+package event
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+const EventTypeCheckURL = "check_url"
+
+// Event 解密后的事件，Data 为完整的事件 JSON
+type Event struct {
+	Type   string
+	ID     string
+	CorpID string
+	Data   json.RawMessage
+}
+
+type envelope struct {
+	EventType string `json:"EventType"`
+	EventID   string `json:"EventId"`
+	CorpID    string `json:"CorpId"`
+}
+
+// ParseEvent 解析事件公共字段，事件体中没有 EventId 时以内容摘要作为 ID，钉钉重推同一事件时内容不变
+func ParseEvent(data []byte) (*Event, error) {
+	env := &envelope{}
+	if err := json.Unmarshal(data, env); err != nil {
+		return nil, fmt.Errorf("event.ParseEvent, invalid json: %w", err)
+	}
+	id := env.EventID
+	if id == "" {
+		sum := sha1.Sum(data)
+		id = hex.EncodeToString(sum[:])
+	}
+	return &Event{
+		Type:   env.EventType,
+		ID:     id,
+		CorpID: env.CorpID,
+		Data:   data,
+	}, nil
+}
+
+// Decode 将事件 JSON 解码到 v
+func (e *Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Data, v)
+}
+
+type HandlerFunc func(ctx context.Context, e *Event) error
+
+// Dispatcher 事件分发，Router 为默认实现
+type Dispatcher interface {
+	Dispatch(ctx context.Context, e *Event) error
+}
+
+// Router 按 EventType 将事件分发到处理函数，未注册的事件类型会被忽略
+type Router struct {
+	mutex       sync.RWMutex
+	handlers    map[string]HandlerFunc
+	fallback    HandlerFunc
+	middlewares []Middleware
+}
+
+func NewRouter() *Router {
+	return &Router{handlers: make(map[string]HandlerFunc)}
+}
+
+func (r *Router) Handle(eventType string, fn HandlerFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.handlers[eventType] = fn
+}
+
+// HandleDefault 注册未匹配任何 EventType 时的处理函数
+func (r *Router) HandleDefault(fn HandlerFunc) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.fallback = fn
+}
+
+// Use 添加中间件，中间件对所有事件生效，包括未注册处理函数的事件
+func (r *Router) Use(middlewares ...Middleware) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+func (r *Router) Dispatch(ctx context.Context, e *Event) error {
+	r.mutex.RLock()
+	fn, ok := r.handlers[e.Type]
+	if !ok {
+		fn = r.fallback
+	}
+	middlewares := r.middlewares
+	r.mutex.RUnlock()
+	if fn == nil {
+		fn = func(ctx context.Context, e *Event) error { return nil }
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		fn = middlewares[i](fn)
+	}
+	return fn(ctx, e)
+}
+
+// On 注册强类型的处理函数，事件 JSON 会先解码为 T
+func On[T any](r *Router, eventType string, fn func(ctx context.Context, e *Event, payload *T) error) {
+	r.Handle(eventType, func(ctx context.Context, e *Event) error {
+		payload := new(T)
+		if err := e.Decode(payload); err != nil {
+			return fmt.Errorf("event: decode %s: %w", e.Type, err)
+		}
+		return fn(ctx, e, payload)
+	})
+}
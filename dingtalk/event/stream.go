@@ -0,0 +1,24 @@
+package event
+
+import (
+	"context"
+
+	"github.com/chzealot/gobase/dingtalk/stream"
+)
+
+// StreamHandler 将 Stream 模式推送的事件交给 Dispatcher，
+// 事件 ID、类型和企业 ID 取自数据帧的 header
+func StreamHandler(d Dispatcher) stream.Handler {
+	return func(ctx context.Context, frame *stream.DataFrame) (interface{}, error) {
+		e := &Event{
+			Type:   frame.Header(stream.HeaderEventType),
+			ID:     frame.Header(stream.HeaderEventID),
+			CorpID: frame.Header(stream.HeaderEventCorpID),
+			Data:   []byte(frame.Data),
+		}
+		if e.ID == "" {
+			e.ID = frame.MessageID()
+		}
+		return nil, d.Dispatch(ctx, e)
+	}
+}
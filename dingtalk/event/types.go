@@ -0,0 +1,139 @@
+package event
+
+import (
+	"context"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+// 事件类型，文档：https://open.dingtalk.com/document/orgapp/event-list
+const (
+	EventTypeUserAddOrg          = "user_add_org"
+	EventTypeUserModifyOrg       = "user_modify_org"
+	EventTypeUserLeaveOrg        = "user_leave_org"
+	EventTypeOrgDeptCreate       = "org_dept_create"
+	EventTypeOrgDeptModify       = "org_dept_modify"
+	EventTypeOrgDeptRemove       = "org_dept_remove"
+	EventTypeCalendarEventChange = "calendar_event_change"
+	EventTypeTodoTaskChange      = "todo_task_change"
+	EventTypeBpmsInstanceChange  = "bpms_instance_change"
+	EventTypeBpmsTaskChange      = "bpms_task_change"
+)
+
+// UserChange 通讯录用户增加、变更、离职
+type UserChange struct {
+	EventType  string   `json:"EventType"`
+	TimeStamp  string   `json:"TimeStamp"`
+	CorpID     string   `json:"CorpId"`
+	UserID     []string `json:"UserId"`
+	OptStaffID string   `json:"OptStaffId"`
+}
+
+// DeptChange 通讯录部门创建、修改、删除
+type DeptChange struct {
+	EventType string  `json:"EventType"`
+	TimeStamp string  `json:"TimeStamp"`
+	CorpID    string  `json:"CorpId"`
+	DeptID    []int64 `json:"DeptId"`
+}
+
+// CalendarEventChange 日程变更，ChangeType 为 create、update、delete
+type CalendarEventChange struct {
+	EventType       string             `json:"EventType"`
+	CorpID          string             `json:"CorpId"`
+	CalendarID      string             `json:"calendarId"`
+	CalendarEventID string             `json:"calendarEventId"`
+	ChangeType      string             `json:"changeType"`
+	UnionIDList     []string           `json:"unionIdList"`
+	BizTime         models.EpochMillis `json:"bizTime"`
+}
+
+// TodoTaskChange 待办变更
+type TodoTaskChange struct {
+	EventType    string             `json:"EventType"`
+	CorpID       string             `json:"CorpId"`
+	TaskID       string             `json:"taskId"`
+	Subject      string             `json:"subject"`
+	ChangeType   string             `json:"changeType"`
+	CreatorID    string             `json:"creatorId"`
+	ExecutorIds  []string           `json:"executorIds"`
+	Done         bool               `json:"done"`
+	DueTime      models.EpochMillis `json:"dueTime"`
+	ModifiedTime models.EpochMillis `json:"modifiedTime"`
+}
+
+// BpmsInstanceChange 审批实例开始、结束、终止，Type 为 start、finish、terminate，Result 为 agree、refuse
+type BpmsInstanceChange struct {
+	EventType         string             `json:"EventType"`
+	CorpID            string             `json:"corpId"`
+	ProcessInstanceID string             `json:"processInstanceId"`
+	ProcessCode       string             `json:"processCode"`
+	BizCategoryID     string             `json:"bizCategoryId"`
+	BusinessID        string             `json:"businessId"`
+	Title             string             `json:"title"`
+	Type              string             `json:"type"`
+	Result            string             `json:"result"`
+	StaffID           string             `json:"staffId"`
+	URL               string             `json:"url"`
+	CreateTime        models.EpochMillis `json:"createTime"`
+	FinishTime        models.EpochMillis `json:"finishTime"`
+}
+
+// BpmsTaskChange 审批任务开始、结束、转交，Type 为 start、finish、cancel
+type BpmsTaskChange struct {
+	EventType         string             `json:"EventType"`
+	CorpID            string             `json:"corpId"`
+	ProcessInstanceID string             `json:"processInstanceId"`
+	ProcessCode       string             `json:"processCode"`
+	BizCategoryID     string             `json:"bizCategoryId"`
+	TaskID            int64              `json:"taskId"`
+	ActivityID        string             `json:"activityId"`
+	Title             string             `json:"title"`
+	Type              string             `json:"type"`
+	Result            string             `json:"result"`
+	Remark            string             `json:"remark"`
+	Content           string             `json:"content"`
+	StaffID           string             `json:"staffId"`
+	CreateTime        models.EpochMillis `json:"createTime"`
+	FinishTime        models.EpochMillis `json:"finishTime"`
+}
+
+func (r *Router) OnUserAddOrg(fn func(ctx context.Context, e *Event, payload *UserChange) error) {
+	On(r, EventTypeUserAddOrg, fn)
+}
+
+func (r *Router) OnUserModifyOrg(fn func(ctx context.Context, e *Event, payload *UserChange) error) {
+	On(r, EventTypeUserModifyOrg, fn)
+}
+
+func (r *Router) OnUserLeaveOrg(fn func(ctx context.Context, e *Event, payload *UserChange) error) {
+	On(r, EventTypeUserLeaveOrg, fn)
+}
+
+func (r *Router) OnOrgDeptCreate(fn func(ctx context.Context, e *Event, payload *DeptChange) error) {
+	On(r, EventTypeOrgDeptCreate, fn)
+}
+
+func (r *Router) OnOrgDeptModify(fn func(ctx context.Context, e *Event, payload *DeptChange) error) {
+	On(r, EventTypeOrgDeptModify, fn)
+}
+
+func (r *Router) OnOrgDeptRemove(fn func(ctx context.Context, e *Event, payload *DeptChange) error) {
+	On(r, EventTypeOrgDeptRemove, fn)
+}
+
+func (r *Router) OnCalendarEventChange(fn func(ctx context.Context, e *Event, payload *CalendarEventChange) error) {
+	On(r, EventTypeCalendarEventChange, fn)
+}
+
+func (r *Router) OnTodoTaskChange(fn func(ctx context.Context, e *Event, payload *TodoTaskChange) error) {
+	On(r, EventTypeTodoTaskChange, fn)
+}
+
+func (r *Router) OnBpmsInstanceChange(fn func(ctx context.Context, e *Event, payload *BpmsInstanceChange) error) {
+	On(r, EventTypeBpmsInstanceChange, fn)
+}
+
+func (r *Router) OnBpmsTaskChange(fn func(ctx context.Context, e *Event, payload *BpmsTaskChange) error) {
+	On(r, EventTypeBpmsTaskChange, fn)
+}
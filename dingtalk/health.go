@@ -0,0 +1,189 @@
+package dingtalk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// probeID 探测时使用的不存在的 ID，接口返回资源不存在说明应用有接口权限
+const probeID = "gobase-validate-probe"
+
+// Probe 权限探测，调用一个接口并根据错误分类判断应用是否有权限，资源不存在同样说明有权限
+type Probe struct {
+	Name     string
+	Endpoint Endpoint
+	Request  interface{}
+
+	// fakeID 请求使用 probeID，参数错误可能由 ID 格式不合法导致，同样说明有权限
+	fakeID bool
+}
+
+// APIProbe 使用任意接口探测权限，接口返回参数错误时检查结果为 CheckError
+func APIProbe(name string, ep Endpoint, req interface{}) Probe {
+	return Probe{Name: name, Endpoint: ep, Request: req}
+}
+
+// idProbe 使用 probeID 调用接口的内置探测
+func idProbe(name string, ep Endpoint, req interface{}) Probe {
+	return Probe{Name: name, Endpoint: ep, Request: req, fakeID: true}
+}
+
+// ProbeUserRead 探测通讯录成员读权限（qyapi_get_member）
+func ProbeUserRead() Probe {
+	return idProbe("user.read", OAPI("/topapi/v2/user/get"), map[string]string{"userid": probeID})
+}
+
+// ProbeCalendarRead 探测日历读权限（Calendar.Calendar.Read）
+func ProbeCalendarRead() Probe {
+	ep := API(http.MethodGet, "/v1.0/calendar/users/{unionId}/calendars")
+	ep.PathParams = []string{probeID}
+	return idProbe("calendar.read", ep, nil)
+}
+
+// ProbeTodoRead 探测待办读权限（Todo.Todo.Read）
+func ProbeTodoRead() Probe {
+	ep := API(http.MethodGet, "/v1.0/todo/users/{unionId}/tasks/sources/{sourceId}")
+	ep.PathParams = []string{probeID, probeID}
+	return idProbe("todo.read", ep, nil)
+}
+
+// CheckStatus 检查结果
+type CheckStatus string
+
+const (
+	CheckOK CheckStatus = "ok"
+	// CheckDenied 凭证错误或缺少接口权限
+	CheckDenied CheckStatus = "denied"
+	// CheckError 限流、服务端错误或网络错误，无法判断是否有权限
+	CheckError CheckStatus = "error"
+)
+
+// CheckResult 单项检查的结果
+type CheckResult struct {
+	Name    string        `json:"name"`
+	Status  CheckStatus   `json:"status"`
+	Code    string        `json:"code,omitempty"`
+	Message string        `json:"message,omitempty"`
+	Latency time.Duration `json:"latency"`
+}
+
+// ValidationReport Validate 的结果，第一项检查为 access_token
+type ValidationReport struct {
+	ClientID      string        `json:"clientId"`
+	TokenExpireAt time.Time     `json:"tokenExpireAt"`
+	Checks        []CheckResult `json:"checks"`
+	CheckedAt     time.Time     `json:"checkedAt"`
+}
+
+// OK 所有检查都通过
+func (r *ValidationReport) OK() bool {
+	for _, check := range r.Checks {
+		if check.Status != CheckOK {
+			return false
+		}
+	}
+	return true
+}
+
+// Err 汇总未通过的检查，全部通过时返回 nil
+func (r *ValidationReport) Err() error {
+	var failures []string
+	for _, check := range r.Checks {
+		if check.Status != CheckOK {
+			failures = append(failures, fmt.Sprintf("%s %s: %s %s", check.Name, check.Status, check.Code, check.Message))
+		}
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+	return errors.New("dingtalk.Validate, " + strings.Join(failures, "; "))
+}
+
+// Validate 重新获取应用 access_token 并依次执行 probes，用于启动时检查凭证和接口权限，
+// 不使用缓存的 token，凭证失效时能及时发现。检查未通过时同时返回报告和错误
+//
+//	report, err := client.Validate(ctx, dingtalk.ProbeUserRead(), dingtalk.ProbeCalendarRead())
+func (c *Client) Validate(ctx context.Context, probes ...Probe) (*ValidationReport, error) {
+	report := &ValidationReport{ClientID: c.ClientID, CheckedAt: time.Now()}
+	start := time.Now()
+	_, err := c.refreshAccessToken(ctx)
+	token := newCheckResult("access_token", err, false, time.Since(start))
+	report.Checks = append(report.Checks, token)
+	if token.Status != CheckOK {
+		return report, report.Err()
+	}
+	report.TokenExpireAt = c.AccessTokenExpireAt()
+	for _, probe := range probes {
+		start := time.Now()
+		err := c.Call(ctx, probe.Endpoint, probe.Request, nil)
+		report.Checks = append(report.Checks, newCheckResult(probe.Name, err, probe.fakeID, time.Since(start)))
+	}
+	return report, report.Err()
+}
+
+func newCheckResult(name string, err error, fakeID bool, latency time.Duration) CheckResult {
+	result := CheckResult{Name: name, Status: CheckOK, Latency: latency}
+	if err == nil {
+		return result
+	}
+	apiErr := &Error{}
+	if errors.As(err, &apiErr) {
+		result.Code = apiErr.Code
+		result.Message = apiErr.Message
+	} else {
+		result.Message = err.Error()
+	}
+	switch kind := ErrorKindOf(err); {
+	case kind == ErrorKindNotFound, kind == ErrorKindInvalidParameter && fakeID:
+		// 资源不存在或内置探测的 ID 不合法，接口已经通过了鉴权
+		result.Code, result.Message = "", ""
+	case kind == ErrorKindAuth, kind == ErrorKindPermission:
+		result.Status = CheckDenied
+	default:
+		result.Status = CheckError
+	}
+	return result
+}
+
+// HealthCheck 缓存 Validate 的结果，用于频繁调用的 HTTP 就绪检查，避免每次都请求钉钉接口
+type HealthCheck struct {
+	client *Client
+	probes []Probe
+	ttl    time.Duration
+
+	mutex  sync.Mutex
+	report *ValidationReport
+	err    error
+}
+
+// NewHealthCheck 创建健康检查，结果在 ttl 内复用
+func NewHealthCheck(client *Client, ttl time.Duration, probes ...Probe) *HealthCheck {
+	return &HealthCheck{client: client, probes: probes, ttl: ttl}
+}
+
+// Check 返回缓存的检查结果，超过 ttl 时重新检查
+func (h *HealthCheck) Check(ctx context.Context) (*ValidationReport, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.report != nil && time.Since(h.report.CheckedAt) < h.ttl {
+		return h.report, h.err
+	}
+	h.report, h.err = h.client.Validate(ctx, h.probes...)
+	return h.report, h.err
+}
+
+// ServeHTTP 检查通过时返回 200，否则返回 503，响应体为 JSON 格式的 ValidationReport
+func (h *HealthCheck) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report, err := h.Check(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
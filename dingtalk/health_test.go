@@ -0,0 +1,90 @@
+package dingtalk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	var tokens int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			atomic.AddInt32(&tokens, 1)
+			if r.URL.Query().Get("appsecret") != "secret" {
+				_, _ = w.Write([]byte(`{"errcode":40089,"errmsg":"invalid appkey or appsecret"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/topapi/v2/user/get":
+			_, _ = w.Write([]byte(`{"errcode":60121,"errmsg":"user not found"}`))
+		case "/v1.0/todo/users/gobase-validate-probe/tasks/sources/gobase-validate-probe", "/v1.0/custom/probe":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":"InvalidParameter.UnionId","message":"unionId is invalid"}`))
+		case "/v1.0/calendar/users/gobase-validate-probe/calendars":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"code":"Forbidden.AccessDenied.AccessTokenPermissionDenied","message":"no Calendar.Calendar.Read"}`))
+		}
+	})
+	ctx := context.Background()
+
+	report, err := client.Validate(ctx, ProbeUserRead(), ProbeCalendarRead())
+	if err == nil || report.OK() || len(report.Checks) != 3 || report.TokenExpireAt.IsZero() {
+		t.Fatalf("report=%+v, err=%v", report, err)
+	}
+	if report.Checks[1].Status != CheckOK || report.Checks[2].Status != CheckDenied ||
+		report.Checks[2].Code != "Forbidden.AccessDenied.AccessTokenPermissionDenied" {
+		t.Errorf("checks = %+v", report.Checks)
+	}
+
+	report, err = client.Validate(ctx, ProbeUserRead())
+	if err != nil || !report.OK() {
+		t.Errorf("user.read only: report=%+v, err=%v", report, err)
+	}
+
+	// 参数错误仅对使用 probeID 的内置探测视为有权限
+	report, err = client.Validate(ctx, ProbeTodoRead(), APIProbe("custom", API(http.MethodGet, "/v1.0/custom/probe"), nil))
+	if err == nil || report.Checks[1].Status != CheckOK || report.Checks[2].Status != CheckError ||
+		report.Checks[2].Code != "InvalidParameter.UnionId" {
+		t.Errorf("invalid parameter: report=%+v, err=%v", report.Checks, err)
+	}
+
+	// 每次都重新获取 access_token，ctx 结束时不使用缓存的 token
+	before := atomic.LoadInt32(&tokens)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	report, err = client.Validate(canceled, ProbeUserRead())
+	if err == nil || len(report.Checks) != 1 || report.Checks[0].Status != CheckError {
+		t.Errorf("canceled: report=%+v, err=%v", report, err)
+	}
+	if n := atomic.LoadInt32(&tokens) - before; n != 0 {
+		t.Errorf("gettoken calls with canceled ctx = %d, want 0", n)
+	}
+
+	client.ClientSecret = "wrong"
+	client.resetAccessToken()
+	report, err = client.Validate(ctx, ProbeUserRead())
+	if err == nil || len(report.Checks) != 1 || report.Checks[0].Status != CheckDenied || report.Checks[0].Code != "40089" {
+		t.Fatalf("wrong secret: report=%+v, err=%v", report, err)
+	}
+
+	// 结果在 ttl 内复用，不再请求 gettoken
+	health := NewHealthCheck(client, time.Minute)
+	before = atomic.LoadInt32(&tokens)
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		health.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+		body := &ValidationReport{}
+		if rec.Code != http.StatusServiceUnavailable || json.Unmarshal(rec.Body.Bytes(), body) != nil || body.ClientID != "id" {
+			t.Fatalf("ready: code=%d, body=%s", rec.Code, rec.Body)
+		}
+	}
+	if n := atomic.LoadInt32(&tokens) - before; n != 1 {
+		t.Errorf("gettoken calls = %d, want 1", n)
+	}
+}
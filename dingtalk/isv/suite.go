@@ -0,0 +1,201 @@
+package isv
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	url2 "net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk"
+	"github.com/chzealot/gobase/dingtalk/event"
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/chzealot/gobase/logger"
+)
+
+// EventTypeSuiteTicket 钉钉每 20 分钟推送一次 suite_ticket
+const EventTypeSuiteTicket = "suite_ticket"
+
+var ErrSuiteTicketNotReady = errors.New("isv: suite_ticket not received yet")
+
+// SuiteTicketStore 保存最新的 suite_ticket，多实例部署时应使用共享存储，
+// 否则未收到推送的实例在重启后无法获取企业凭证
+type SuiteTicketStore interface {
+	GetSuiteTicket(ctx context.Context) (string, error)
+	SetSuiteTicket(ctx context.Context, ticket string) error
+}
+
+// MemorySuiteTicketStore 进程内的 SuiteTicketStore
+type MemorySuiteTicketStore struct {
+	mutex  sync.RWMutex
+	ticket string
+}
+
+func (s *MemorySuiteTicketStore) GetSuiteTicket(ctx context.Context) (string, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.ticket, nil
+}
+
+func (s *MemorySuiteTicketStore) SetSuiteTicket(ctx context.Context, ticket string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.ticket = ticket
+	return nil
+}
+
+// Suite 第三方企业应用，按 corpId 管理各授权企业的凭证
+type Suite struct {
+	SuiteKey    string
+	SuiteSecret string
+	store       SuiteTicketStore
+	opts        []dingtalk.Option
+	// client 仅用于调用不需要企业 access_token 的 /service 接口
+	client *dingtalk.Client
+
+	mutex   sync.Mutex
+	clients map[string]*dingtalk.Client
+}
+
+// NewSuite store 为 nil 时使用 MemorySuiteTicketStore，opts 同时用于 CorpClient 返回的 Client
+func NewSuite(suiteKey, suiteSecret string, store SuiteTicketStore, opts ...dingtalk.Option) *Suite {
+	if store == nil {
+		store = &MemorySuiteTicketStore{}
+	}
+	return &Suite{
+		SuiteKey:    suiteKey,
+		SuiteSecret: suiteSecret,
+		store:       store,
+		opts:        opts,
+		client:      dingtalk.NewDingTalkClient(suiteKey, suiteSecret, opts...),
+		clients:     make(map[string]*dingtalk.Client),
+	}
+}
+
+type suiteTicketEvent struct {
+	SuiteKey    string `json:"SuiteKey"`
+	SuiteTicket string `json:"SuiteTicket"`
+}
+
+// HandleSuiteTicket 处理 suite_ticket 推送事件
+func (s *Suite) HandleSuiteTicket(ctx context.Context, e *event.Event) error {
+	payload := &suiteTicketEvent{}
+	if err := e.Decode(payload); err != nil {
+		return err
+	}
+	if payload.SuiteTicket == "" {
+		return errors.New("isv.HandleSuiteTicket, empty SuiteTicket")
+	}
+	if payload.SuiteKey != "" && payload.SuiteKey != s.SuiteKey {
+		return fmt.Errorf("isv.HandleSuiteTicket, unexpected suiteKey %s", payload.SuiteKey)
+	}
+	logger.InfowCtx(ctx, "isv.Suite, suite_ticket updated", "suiteKey", s.SuiteKey)
+	return s.store.SetSuiteTicket(ctx, payload.SuiteTicket)
+}
+
+// Register 在事件 Router 上注册 suite_ticket 处理函数，
+// 回调的 Crypto 需要使用 suiteKey 作为 ownerKey
+func (s *Suite) Register(r *event.Router) {
+	r.Handle(EventTypeSuiteTicket, s.HandleSuiteTicket)
+}
+
+// CorpClient 返回授权企业的 dingtalk.Client，access_token 使用企业凭证并按 corpId 分别缓存。
+// 返回的 Client 与企业内部应用的 Client 用法一致，需要 AgentID 时可从 GetAuthInfo 获取后设置
+func (s *Suite) CorpClient(corpId string) *dingtalk.Client {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if client, ok := s.clients[corpId]; ok {
+		return client
+	}
+	client := dingtalk.NewDingTalkClientWithTokenSource(s.SuiteKey, s.SuiteSecret,
+		&corpTokenSource{suite: s, corpId: corpId}, s.opts...)
+	client.CorpID = corpId
+	s.clients[corpId] = client
+	return client
+}
+
+// GetCorpAccessToken 获取授权企业的 access_token
+func (s *Suite) GetCorpAccessToken(corpId string) (string, error) {
+	return s.CorpClient(corpId).GetAccessToken()
+}
+
+type corpTokenSource struct {
+	suite  *Suite
+	corpId string
+}
+
+func (t *corpTokenSource) Token() (*models.GetTokenResponse, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/isvapp/obtains-the-enterprise-authorized-credential
+	resp := &models.GetTokenResponse{}
+	if err := t.suite.signedRequest(context.Background(), "/service/get_corp_token",
+		models.AuthCorpRequest{AuthCorpID: t.corpId}, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetAuthInfo 获取企业授权信息，包括企业信息和授权的应用
+func (s *Suite) GetAuthInfo(ctx context.Context, corpId string) (*models.GetAuthInfoResponse, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/isvapp/obtains-the-basic-information-of-an-enterprise
+	resp := &models.GetAuthInfoResponse{}
+	if err := s.signedRequest(ctx, "/service/get_auth_info",
+		models.AuthCorpRequest{AuthCorpID: corpId}, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetSuiteAccessToken 获取第三方应用的 suite_access_token
+func (s *Suite) GetSuiteAccessToken(ctx context.Context) (*models.GetSuiteTokenResponse, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/isvapp/obtains-the-suite_access_token-of-third-party-enterprise-applications
+	ticket, err := s.suiteTicket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req := models.GetSuiteTokenRequest{SuiteKey: s.SuiteKey, SuiteSecret: s.SuiteSecret, SuiteTicket: ticket}
+	resp := &models.GetSuiteTokenResponse{}
+	ep := dingtalk.OAPI("/service/get_suite_token")
+	ep.NoAuth = true
+	if err = s.client.Call(ctx, ep, req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (s *Suite) suiteTicket(ctx context.Context) (string, error) {
+	ticket, err := s.store.GetSuiteTicket(ctx)
+	if err != nil {
+		return "", err
+	}
+	if ticket == "" {
+		return "", ErrSuiteTicketNotReady
+	}
+	return ticket, nil
+}
+
+// signedRequest 使用 accessKey、timestamp、suiteTicket、signature 签名调用 /service 接口
+func (s *Suite) signedRequest(ctx context.Context, path string, req, resp interface{}) error {
+	ticket, err := s.suiteTicket(ctx)
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	query := url2.Values{}
+	query.Set("accessKey", s.SuiteKey)
+	query.Set("timestamp", timestamp)
+	query.Set("suiteTicket", ticket)
+	query.Set("signature", Signature(timestamp, ticket, s.SuiteSecret))
+	return s.client.Call(ctx, dingtalk.Endpoint{Family: dingtalk.FamilyOAPI, Path: path, Query: query, NoAuth: true}, req, resp)
+}
+
+// Signature 计算第三方应用接口签名：base64(HmacSHA256(timestamp + "\n" + suiteTicket))
+func Signature(timestamp, suiteTicket, suiteSecret string) string {
+	mac := hmac.New(sha256.New, []byte(suiteSecret))
+	mac.Write([]byte(timestamp + "\n" + suiteTicket))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,101 @@
+package isv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/chzealot/gobase/dingtalk"
+	"github.com/chzealot/gobase/dingtalk/event"
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/chzealot/gobase/logger"
+)
+
+func TestSignature(t *testing.T) {
+	// base64(HmacSHA256(suiteSecret, timestamp + "\n" + suiteTicket))
+	got := Signature("1700000000000", "ticket-1", "suite-secret")
+	if want := "zTP0qqOT5CrM1Gss2ao6ijaspXi0j3mGZ5iblCOMxPg="; got != want {
+		t.Errorf("Signature() = %s, want %s", got, want)
+	}
+}
+
+func TestCorpClient(t *testing.T) {
+	_ = logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff})
+	var corpTokens int32
+	expiresIn := 7200
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/service/get_corp_token":
+			atomic.AddInt32(&corpTokens, 1)
+			if query.Get("accessKey") != "suite-key" || query.Get("suiteTicket") != "ticket-1" ||
+				query.Get("signature") != Signature(query.Get("timestamp"), "ticket-1", "suite-secret") {
+				_, _ = w.Write([]byte(`{"errcode":40001,"errmsg":"invalid signature"}`))
+				return
+			}
+			req := &models.AuthCorpRequest{}
+			_ = json.NewDecoder(r.Body).Decode(req)
+			_ = json.NewEncoder(w).Encode(models.GetTokenResponse{AccessToken: "token-" + req.AuthCorpID, ExpiresIn: expiresIn})
+		case "/topapi/v2/user/get":
+			_, _ = w.Write([]byte(`{"errcode":0,"result":{"userid":"u1","name":"` + query.Get("access_token") + `"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	suite := NewSuite("suite-key", "suite-secret", nil, dingtalk.WithBaseURL(server.URL, server.URL))
+	if _, err := suite.GetCorpAccessToken("corp1"); !errors.Is(err, ErrSuiteTicketNotReady) {
+		t.Fatalf("GetCorpAccessToken() before suite_ticket error = %v", err)
+	}
+
+	router := event.NewRouter()
+	suite.Register(router)
+	e, _ := event.ParseEvent([]byte(`{"EventType":"suite_ticket","SuiteKey":"suite-key","SuiteTicket":"ticket-1"}`))
+	if err := router.Dispatch(context.Background(), e); err != nil {
+		t.Fatalf("Dispatch(suite_ticket) error = %v", err)
+	}
+	e, _ = event.ParseEvent([]byte(`{"EventType":"suite_ticket","SuiteKey":"other","SuiteTicket":"ticket-2"}`))
+	if err := router.Dispatch(context.Background(), e); err == nil {
+		t.Error("Dispatch(suite_ticket of other suite) error = nil")
+	}
+
+	// 企业凭证按 corpId 分别缓存
+	for i := 0; i < 2; i++ {
+		token, err := suite.GetCorpAccessToken("corp1")
+		if err != nil || token != "token-corp1" {
+			t.Fatalf("GetCorpAccessToken(corp1) = %q, %v", token, err)
+		}
+	}
+	if token, err := suite.GetCorpAccessToken("corp2"); err != nil || token != "token-corp2" {
+		t.Fatalf("GetCorpAccessToken(corp2) = %q, %v", token, err)
+	}
+	if n := atomic.LoadInt32(&corpTokens); n != 2 {
+		t.Errorf("get_corp_token calls = %d, want 2", n)
+	}
+	if client := suite.CorpClient("corp1"); client != suite.CorpClient("corp1") || client.CorpID != "corp1" {
+		t.Error("CorpClient() should return the cached client of the corp")
+	}
+	user, err := suite.CorpClient("corp2").GetUserFromTop("u1")
+	if err != nil || user.Name != "token-corp2" {
+		t.Errorf("GetUserFromTop() with corp token = %+v, %v", user, err)
+	}
+
+	// 有效期不足一分钟的凭证不缓存
+	expiresIn = 30
+	suite = NewSuite("suite-key", "suite-secret", &MemorySuiteTicketStore{ticket: "ticket-1"},
+		dingtalk.WithBaseURL(server.URL, server.URL))
+	atomic.StoreInt32(&corpTokens, 0)
+	for i := 0; i < 2; i++ {
+		if _, err := suite.GetCorpAccessToken("corp1"); err != nil {
+			t.Fatalf("GetCorpAccessToken() error = %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(&corpTokens); n != 2 {
+		t.Errorf("get_corp_token calls for expiring token = %d, want 2", n)
+	}
+}
@@ -0,0 +1,103 @@
+package dingtalk
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	url2 "net/url"
+	"strings"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+// GetJsapiTicket 获取 jsapi_ticket，和 GetAccessToken 一样在有效期内使用缓存
+func (c *Client) GetJsapiTicket() (string, error) {
+	ticket := ""
+	{
+		// 先查询缓存
+		c.mutex.Lock()
+		now := time.Now().Unix()
+		if c.jsapiTicketExpireAt > 0 && c.jsapiTicket != "" && (now+60) < c.jsapiTicketExpireAt {
+			ticket = c.jsapiTicket
+		}
+		c.mutex.Unlock()
+	}
+	if ticket != "" {
+		return ticket, nil
+	}
+
+	ticketResult, err := c.getJsapiTicketFromAPI()
+	if err != nil {
+		return "", err
+	}
+
+	{
+		// 更新缓存
+		c.mutex.Lock()
+		c.jsapiTicket = ticketResult.Ticket
+		c.jsapiTicketExpireAt = time.Now().Unix() + int64(ticketResult.ExpiresIn)
+		c.mutex.Unlock()
+	}
+	return ticketResult.Ticket, nil
+}
+
+func (c *Client) getJsapiTicketFromAPI() (*models.GetJsapiTicketResponse, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/obtain-jsapi_ticket
+	ep := Endpoint{Family: FamilyOAPI, Method: http.MethodGet, Path: "/get_jsapi_ticket"}
+	response := &models.GetJsapiTicketResponse{}
+	if err := c.Call(context.Background(), ep, nil, response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// SignJsapi 计算 dd.config 的签名：sha1("jsapi_ticket=...&noncestr=...&timestamp=...&url=...")
+// 文档：https://open.dingtalk.com/document/orgapp/jsapi-authentication
+func SignJsapi(ticket, nonceStr string, timeStamp int64, url string) string {
+	plain := fmt.Sprintf("jsapi_ticket=%s&noncestr=%s&timestamp=%d&url=%s", ticket, nonceStr, timeStamp, url)
+	sum := sha1.Sum([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// SignJsapiConfig 为当前页面 url 生成 dd.config 参数，需要设置 Client.AgentID 和 Client.CorpID
+func (c *Client) SignJsapiConfig(url string) (*models.JsapiConfig, error) {
+	if c.AgentID == "" || c.CorpID == "" {
+		return nil, errors.New("dingtalk.SignJsapiConfig, AgentID and CorpID are required")
+	}
+	ticket, err := c.GetJsapiTicket()
+	if err != nil {
+		return nil, err
+	}
+	// 签名使用的 url 需要解码且不包含 # 之后的部分，与前端 decodeURIComponent 一致，不将 + 解码为空格
+	if i := strings.Index(url, "#"); i >= 0 {
+		url = url[:i]
+	}
+	if decoded, err := url2.PathUnescape(url); err == nil {
+		url = decoded
+	}
+	nonceStr, err := newNonceStr()
+	if err != nil {
+		return nil, err
+	}
+	timeStamp := time.Now().UnixMilli()
+	return &models.JsapiConfig{
+		AgentID:   c.AgentID,
+		CorpID:    c.CorpID,
+		TimeStamp: timeStamp,
+		NonceStr:  nonceStr,
+		Signature: SignJsapi(ticket, nonceStr, timeStamp, url),
+	}, nil
+}
+
+func newNonceStr() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
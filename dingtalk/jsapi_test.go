@@ -0,0 +1,72 @@
+package dingtalk
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+const testJsapiTicket = "zHoQdGJuH0ZDebwo7sLqLzHGUueLmkWCC4RycYgkuvDu3eoROgN5qhwnQLgfzwEP"
+
+func TestSignJsapi(t *testing.T) {
+	// 按文档拼接后使用 sha1 计算的结果
+	got := SignJsapi(testJsapiTicket, "abcdefg", 1414588745000, "http://open.dingtalk.com/index.html?a=1&b=中文")
+	if want := "5d460c02c120bd3ce0ec6c616ca38c69b2d2246d"; got != want {
+		t.Errorf("SignJsapi() = %s, want %s", got, want)
+	}
+}
+
+func TestSignJsapiConfig(t *testing.T) {
+	var tickets int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/get_jsapi_ticket":
+			atomic.AddInt32(&tickets, 1)
+			if r.Method != http.MethodGet || r.URL.Query().Get("access_token") != "app-token" {
+				t.Errorf("get_jsapi_ticket request = %s %s", r.Method, r.URL)
+			}
+			_, _ = w.Write([]byte(`{"errcode":0,"ticket":"` + testJsapiTicket + `","expires_in":7200}`))
+		}
+	})
+
+	if _, err := client.SignJsapiConfig("http://open.dingtalk.com/index.html"); err == nil {
+		t.Error("SignJsapiConfig() without AgentID error = nil")
+	}
+	client.AgentID, client.CorpID = "agent1", "ding1"
+
+	// 签名使用解码后、不包含 # 之后部分的 url
+	config, err := client.SignJsapiConfig("http://open.dingtalk.com/index.html?a=1&b=%E4%B8%AD%E6%96%87#/home")
+	if err != nil {
+		t.Fatalf("SignJsapiConfig() error = %v", err)
+	}
+	want := SignJsapi(testJsapiTicket, config.NonceStr, config.TimeStamp, "http://open.dingtalk.com/index.html?a=1&b=中文")
+	if config.Signature != want || config.AgentID != "agent1" || config.CorpID != "ding1" || config.NonceStr == "" {
+		t.Errorf("SignJsapiConfig() = %+v, want signature %s", config, want)
+	}
+
+	// + 不是空格，按原样签名
+	config, err = client.SignJsapiConfig("http://open.dingtalk.com/index.html?q=a+b%2Bc")
+	if err != nil {
+		t.Fatalf("SignJsapiConfig() error = %v", err)
+	}
+	if want := SignJsapi(testJsapiTicket, config.NonceStr, config.TimeStamp, "http://open.dingtalk.com/index.html?q=a+b+c"); config.Signature != want {
+		t.Errorf("SignJsapiConfig(+) signature = %s, want %s", config.Signature, want)
+	}
+	// 后续签名使用缓存的 ticket
+	if n := atomic.LoadInt32(&tickets); n != 1 {
+		t.Errorf("get_jsapi_ticket calls = %d, want 1", n)
+	}
+
+	// 缓存即将过期时重新获取
+	client.mutex.Lock()
+	client.jsapiTicketExpireAt = 1
+	client.mutex.Unlock()
+	if ticket, err := client.GetJsapiTicket(); err != nil || ticket != testJsapiTicket {
+		t.Errorf("GetJsapiTicket() = %q, %v", ticket, err)
+	}
+	if n := atomic.LoadInt32(&tickets); n != 2 {
+		t.Errorf("get_jsapi_ticket calls after expiry = %d, want 2", n)
+	}
+}
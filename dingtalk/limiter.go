@@ -0,0 +1,222 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+var (
+	// ErrRateLimited 使用 LimitFailFast 策略时，令牌不足直接返回该错误
+	ErrRateLimited = errors.New("dingtalk: client-side rate limit exceeded")
+	// ErrDailyQuotaExceeded 当天的调用次数已用完，无论哪种策略都直接返回
+	ErrDailyQuotaExceeded = errors.New("dingtalk: client-side daily quota exceeded")
+)
+
+// LimitPolicy 令牌不足时的处理策略
+type LimitPolicy int
+
+const (
+	// LimitWait 等待令牌，ctx 取消时返回 ctx.Err()
+	LimitWait LimitPolicy = iota
+	// LimitFailFast 直接返回 ErrRateLimited
+	LimitFailFast
+)
+
+// Limit 单个接口的限制，QPS 为 0 时不限制频率，Daily 为 0 时不限制每日调用次数
+type Limit struct {
+	QPS   float64
+	Burst int
+	Daily int
+}
+
+// DefaultLimit 钉钉企业内部应用单个接口默认 20 QPS，DefaultEndpointLimits 和 SetLimit 之外的接口都使用该限制
+// 文档：https://open.dingtalk.com/document/orgapp/invocation-frequency-limit
+var DefaultLimit = Limit{QPS: 20, Burst: 20}
+
+// DefaultEndpointLimits 限制低于 DefaultLimit 的接口，NewDefaultLimiter 创建的限流器会使用这些限制，
+// 应用的实际额度不同时可以通过 SetLimit 覆盖
+var DefaultEndpointLimits = map[string]Limit{
+	// 发送工作通知
+	"/topapi/message/corpconversation/asyncsend_v2": {QPS: 5, Daily: 5000},
+	// 获取考勤打卡详情
+	"/attendance/listRecord": {QPS: 10},
+}
+
+// LimiterState 某个接口当前的限流状态，可用于在触发钉钉限流前告警
+type LimiterState struct {
+	Endpoint   string
+	Limit      Limit
+	Tokens     float64
+	DailyUsed  int
+	Waiting    int
+	LastUsedAt time.Time
+}
+
+type bucket struct {
+	limit     Limit
+	tokens    float64
+	updatedAt time.Time
+	day       string
+	dailyUsed int
+	waiting   int
+	lastUsed  time.Time
+}
+
+// Limiter 按接口（Endpoint.Path 模板）分别计算的令牌桶，每日额度按东八区自然日重置
+type Limiter struct {
+	mutex        sync.Mutex
+	policy       LimitPolicy
+	defaultLimit Limit
+	limits       map[string]Limit
+	buckets      map[string]*bucket
+	now          func() time.Time
+}
+
+func NewLimiter(policy LimitPolicy, defaultLimit Limit) *Limiter {
+	return &Limiter{
+		policy:       policy,
+		defaultLimit: defaultLimit,
+		limits:       make(map[string]Limit),
+		buckets:      make(map[string]*bucket),
+		now:          time.Now,
+	}
+}
+
+// NewDefaultLimiter 返回客户端默认使用的限流器：令牌不足时等待，DefaultEndpointLimits 中的接口使用各自的限制，
+// 其他接口使用 DefaultLimit
+func NewDefaultLimiter() *Limiter {
+	l := NewLimiter(LimitWait, DefaultLimit)
+	for endpoint, limit := range DefaultEndpointLimits {
+		l.SetLimit(endpoint, limit)
+	}
+	return l
+}
+
+// SetLimit 设置单个接口的限制，endpoint 为 Endpoint.Path，如 /v1.0/contact/users/{unionId}
+func (l *Limiter) SetLimit(endpoint string, limit Limit) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.limits[endpoint] = limit
+	if b, ok := l.buckets[endpoint]; ok {
+		b.limit = limit
+		if b.tokens > float64(limit.burst()) {
+			b.tokens = float64(limit.burst())
+		}
+	}
+}
+
+func (limit Limit) burst() int {
+	if limit.Burst > 0 {
+		return limit.Burst
+	}
+	if limit.QPS >= 1 {
+		return int(limit.QPS)
+	}
+	return 1
+}
+
+func (l *Limiter) getBucket(endpoint string, now time.Time) *bucket {
+	b, ok := l.buckets[endpoint]
+	if !ok {
+		limit, ok := l.limits[endpoint]
+		if !ok {
+			limit = l.defaultLimit
+		}
+		b = &bucket{limit: limit, tokens: float64(limit.burst()), updatedAt: now}
+		l.buckets[endpoint] = b
+	}
+	if elapsed := now.Sub(b.updatedAt).Seconds(); elapsed > 0 && b.limit.QPS > 0 {
+		b.tokens += elapsed * b.limit.QPS
+		if max := float64(b.limit.burst()); b.tokens > max {
+			b.tokens = max
+		}
+	}
+	b.updatedAt = now
+	if day := now.In(models.DefaultLocation).Format("2006-01-02"); day != b.day {
+		b.day = day
+		b.dailyUsed = 0
+	}
+	return b
+}
+
+// Wait 获取 endpoint 的一个令牌
+func (l *Limiter) Wait(ctx context.Context, endpoint string) error {
+	l.mutex.Lock()
+	now := l.now()
+	b := l.getBucket(endpoint, now)
+	if b.limit.Daily > 0 && b.dailyUsed >= b.limit.Daily {
+		l.mutex.Unlock()
+		return ErrDailyQuotaExceeded
+	}
+	var delay time.Duration
+	if b.limit.QPS > 0 {
+		if b.tokens < 1 {
+			if l.policy == LimitFailFast {
+				l.mutex.Unlock()
+				return ErrRateLimited
+			}
+			delay = time.Duration((1 - b.tokens) / b.limit.QPS * float64(time.Second))
+		}
+		// 预占令牌，等待期间其他调用按顺序排在后面
+		b.tokens--
+	}
+	b.dailyUsed++
+	b.lastUsed = now
+	if delay <= 0 {
+		l.mutex.Unlock()
+		return nil
+	}
+	b.waiting++
+	l.mutex.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		l.mutex.Lock()
+		b.waiting--
+		l.mutex.Unlock()
+		return nil
+	case <-ctx.Done():
+		// 归还预占的令牌和额度，等待期间令牌可能已经补充，归还后不超过 burst
+		l.mutex.Lock()
+		b.waiting--
+		b.tokens++
+		if max := float64(b.limit.burst()); b.tokens > max {
+			b.tokens = max
+		}
+		if b.dailyUsed > 0 {
+			b.dailyUsed--
+		}
+		l.mutex.Unlock()
+		return ctx.Err()
+	}
+}
+
+// State 返回所有调用过的接口的限流状态，按 endpoint 排序
+func (l *Limiter) State() []LimiterState {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	now := l.now()
+	states := make([]LimiterState, 0, len(l.buckets))
+	for endpoint := range l.buckets {
+		b := l.getBucket(endpoint, now)
+		states = append(states, LimiterState{
+			Endpoint:   endpoint,
+			Limit:      b.limit,
+			Tokens:     b.tokens,
+			DailyUsed:  b.dailyUsed,
+			Waiting:    b.waiting,
+			LastUsedAt: b.lastUsed,
+		})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Endpoint < states[j].Endpoint
+	})
+	return states
+}
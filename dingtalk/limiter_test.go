@@ -0,0 +1,129 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLimiterFailFastAndRefill(t *testing.T) {
+	now := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	l := NewLimiter(LimitFailFast, Limit{QPS: 2, Burst: 2})
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if err := l.Wait(ctx, "/topapi/a"); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+	}
+	if err := l.Wait(ctx, "/topapi/a"); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected ErrRateLimited, got %v", err)
+	}
+	// 其他接口使用独立的令牌桶
+	if err := l.Wait(ctx, "/topapi/b"); err != nil {
+		t.Fatalf("other endpoint: %v", err)
+	}
+	now = now.Add(500 * time.Millisecond)
+	if err := l.Wait(ctx, "/topapi/a"); err != nil {
+		t.Fatalf("after refill: %v", err)
+	}
+
+	states := l.State()
+	if len(states) != 2 || states[0].Endpoint != "/topapi/a" || states[0].DailyUsed != 3 {
+		t.Errorf("states = %+v", states)
+	}
+}
+
+func TestLimiterDailyQuota(t *testing.T) {
+	now := time.Date(2023, 3, 1, 15, 0, 0, 0, time.UTC)
+	l := NewLimiter(LimitWait, DefaultLimit)
+	l.now = func() time.Time { return now }
+	l.SetLimit("/topapi/send", Limit{Daily: 1})
+	ctx := context.Background()
+
+	if err := l.Wait(ctx, "/topapi/send"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Wait(ctx, "/topapi/send"); !errors.Is(err, ErrDailyQuotaExceeded) {
+		t.Fatalf("expected ErrDailyQuotaExceeded, got %v", err)
+	}
+	// 东八区 0 点重置
+	now = time.Date(2023, 3, 1, 16, 0, 0, 0, time.UTC)
+	if err := l.Wait(ctx, "/topapi/send"); err != nil {
+		t.Fatalf("after reset: %v", err)
+	}
+}
+
+func TestLimiterWaitRespectsContext(t *testing.T) {
+	l := NewLimiter(LimitWait, Limit{QPS: 0.5, Burst: 1})
+	if err := l.Wait(context.Background(), "/v1.0/x"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "/v1.0/x"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	state := l.State()[0]
+	if state.Waiting != 0 || state.DailyUsed != 1 {
+		t.Errorf("state after cancel = %+v", state)
+	}
+}
+
+func TestDefaultLimiterEndpointLimits(t *testing.T) {
+	now := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	l := NewDefaultLimiter()
+	l.now = func() time.Time { return now }
+	for _, endpoint := range []string{"/topapi/message/corpconversation/asyncsend_v2", "/attendance/listRecord", "/topapi/v2/user/get"} {
+		if err := l.Wait(context.Background(), endpoint); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := map[string]Limit{
+		"/attendance/listRecord":                        {QPS: 10},
+		"/topapi/message/corpconversation/asyncsend_v2": {QPS: 5, Daily: 5000},
+		"/topapi/v2/user/get":                           DefaultLimit,
+	}
+	for _, state := range l.State() {
+		if state.Limit != want[state.Endpoint] || state.Tokens != float64(state.Limit.burst()-1) {
+			t.Errorf("%s state = %+v", state.Endpoint, state)
+		}
+	}
+	if limiter := NewDingTalkClient("id", "secret").Limiter(); limiter == nil || limiter.limits["/attendance/listRecord"] != want["/attendance/listRecord"] {
+		t.Error("client should use the default limiter with endpoint limits")
+	}
+}
+
+func TestLimiterRefundCapsAtBurst(t *testing.T) {
+	var now int64 = time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC).UnixNano()
+	l := NewLimiter(LimitWait, Limit{QPS: 1, Burst: 1})
+	l.now = func() time.Time { return time.Unix(0, atomic.LoadInt64(&now)) }
+	if err := l.Wait(context.Background(), "/v1.0/x"); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- l.Wait(ctx, "/v1.0/x") }()
+	for deadline := time.Now().Add(time.Second); ; {
+		if state := l.State(); state[0].Waiting == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("second call is not waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// 等待期间令牌已经补满，取消后归还的令牌不能超过 burst
+	atomic.AddInt64(&now, int64(10*time.Second))
+	_ = l.State()
+	cancel()
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait() = %v, want context.Canceled", err)
+	}
+	if state := l.State()[0]; state.Tokens != 1 {
+		t.Errorf("tokens after refund = %v, want 1", state.Tokens)
+	}
+}
@@ -0,0 +1,193 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	url2 "net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+type MediaType string
+
+const (
+	MediaTypeImage MediaType = "image"
+	MediaTypeVoice MediaType = "voice"
+	MediaTypeVideo MediaType = "video"
+	MediaTypeFile  MediaType = "file"
+)
+
+var ErrMediaTooLarge = errors.New("dingtalk: media exceeds size limit")
+
+type mediaLimit struct {
+	maxSize    int64
+	extensions []string
+}
+
+// 钉钉对各类媒体文件的大小和格式限制
+// 文档：https://open.dingtalk.com/document/orgapp/upload-media-files
+var mediaLimits = map[MediaType]mediaLimit{
+	MediaTypeImage: {maxSize: 20 << 20, extensions: []string{".jpg", ".jpeg", ".png", ".gif", ".bmp"}},
+	MediaTypeVoice: {maxSize: 2 << 20, extensions: []string{".amr", ".mp3", ".wav"}},
+	MediaTypeVideo: {maxSize: 20 << 20, extensions: []string{".mp4"}},
+	MediaTypeFile: {maxSize: 20 << 20, extensions: []string{".doc", ".docx", ".xls", ".xlsx", ".ppt", ".pptx",
+		".zip", ".pdf", ".rar"}},
+}
+
+func validateMedia(mediaType MediaType, filename string, r io.Reader) (mediaLimit, error) {
+	limit, ok := mediaLimits[mediaType]
+	if !ok {
+		return limit, fmt.Errorf("dingtalk.UploadMedia, unsupported media type %q", mediaType)
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	supported := false
+	for _, e := range limit.extensions {
+		if e == ext {
+			supported = true
+			break
+		}
+	}
+	if !supported {
+		return limit, fmt.Errorf("dingtalk.UploadMedia, %s does not support %q, supported: %s",
+			mediaType, ext, strings.Join(limit.extensions, ","))
+	}
+	// bytes.Reader、strings.Reader 等可以提前知道大小
+	if sized, ok := r.(interface{ Len() int }); ok && int64(sized.Len()) > limit.maxSize {
+		return limit, ErrMediaTooLarge
+	}
+	if sized, ok := r.(interface{ Size() int64 }); ok && sized.Size() > limit.maxSize {
+		return limit, ErrMediaTooLarge
+	}
+	return limit, nil
+}
+
+// limitedReader 超过 limit 时返回 ErrMediaTooLarge，而不是像 io.LimitReader 一样静默截断
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n, ErrMediaTooLarge
+	}
+	return n, err
+}
+
+// multipartBody 以流的方式将 r 编码为只有一个文件字段的 multipart 请求体，不会整体读入内存。
+// r 实现了 io.Seeker 时（如 *os.File、*bytes.Reader）请求体可以重放，用于 token 刷新和重试
+func multipartBody(field, filename string, r io.Reader, maxSize int64) *requestBody {
+	seeker, replayable := r.(io.Seeker)
+	var offset int64
+	if replayable {
+		var err error
+		if offset, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			replayable = false
+		}
+	}
+	// 每次尝试使用相同的 boundary，Content-Type 保持不变
+	boundary := multipart.NewWriter(io.Discard)
+	opened := false
+	return &requestBody{
+		contentType: boundary.FormDataContentType(),
+		replayable:  replayable,
+		open: func() (io.Reader, func() error, error) {
+			if opened {
+				if !replayable {
+					return nil, nil, errors.New("dingtalk.UploadMedia, request body cannot be replayed")
+				}
+				if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+					return nil, nil, err
+				}
+			}
+			opened = true
+			pr, pw := io.Pipe()
+			mw := multipart.NewWriter(pw)
+			if err := mw.SetBoundary(boundary.Boundary()); err != nil {
+				return nil, nil, err
+			}
+			writeDone := make(chan error, 1)
+			go func() {
+				err := func() error {
+					part, err := mw.CreateFormFile(field, filename)
+					if err != nil {
+						return err
+					}
+					if _, err = io.Copy(part, &limitedReader{r: r, limit: maxSize}); err != nil {
+						return err
+					}
+					return mw.Close()
+				}()
+				_ = pw.CloseWithError(err)
+				writeDone <- err
+			}()
+			wait := func() error {
+				// 服务端可能在读完请求体之前就返回，关闭读端以结束写入的 goroutine
+				_ = pr.Close()
+				if err := <-writeDone; err != nil && !errors.Is(err, io.ErrClosedPipe) {
+					return err
+				}
+				return nil
+			}
+			return pr, wait, nil
+		},
+	}
+}
+
+// UploadMedia 上传媒体文件，返回 media_id。文件内容以流的方式编码为 multipart，不会整体读入内存；
+// r 实现了 io.Seeker 时 token 失效或限流后会重新上传
+func (c *Client) UploadMedia(ctx context.Context, mediaType MediaType, filename string, r io.Reader) (*models.UploadMediaResponse, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/upload-media-files
+	limit, err := validateMedia(mediaType, filename, r)
+	if err != nil {
+		return nil, err
+	}
+	ep := OAPI("/media/upload")
+	ep.Query = url2.Values{"type": {string(mediaType)}}
+	resp := &models.UploadMediaResponse{}
+	if err := c.Call(ctx, ep, multipartBody("media", filepath.Base(filename), r, limit.maxSize), resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// DownloadMedia 下载 UploadMedia 上传的文件，调用方负责关闭返回的 io.ReadCloser
+func (c *Client) DownloadMedia(ctx context.Context, mediaId string) (io.ReadCloser, error) {
+	ep := Endpoint{Family: FamilyOAPI, Method: http.MethodGet, Path: "/media/downloadFile"}
+	ep.Query = url2.Values{"media_id": {mediaId}}
+	resp := &streamResponse{}
+	if err := c.Call(ctx, ep, nil, resp); err != nil {
+		return nil, err
+	}
+	return resp.body, nil
+}
+
+// DownloadRobotMessageFile 下载机器人收到的图片、语音、文件等消息内容，
+// downloadCode 取自消息 content，robotCode 为机器人编码
+func (c *Client) DownloadRobotMessageFile(ctx context.Context, downloadCode, robotCode string) (io.ReadCloser, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/download-the-file-content-of-the-robot-receiving-message
+	req := models.RobotMessageFileDownloadRequest{DownloadCode: downloadCode, RobotCode: robotCode}
+	resp := &models.RobotMessageFileDownloadResponse{}
+	if err := c.doApi(ctx, http.MethodPost, "/v1.0/robot/messageFiles/download", nil, req, resp); err != nil {
+		return nil, err
+	}
+	if resp.DownloadURL == "" {
+		return nil, errors.New("dingtalk.DownloadRobotMessageFile, empty downloadUrl")
+	}
+	// 下载地址已经签名，不需要 access_token
+	ep := Endpoint{Family: FamilyAPI, Method: http.MethodGet, Path: "{downloadUrl}", RawURL: resp.DownloadURL, NoAuth: true}
+	file := &streamResponse{}
+	if err := c.Call(ctx, ep, nil, file); err != nil {
+		return nil, err
+	}
+	return file.body, nil
+}
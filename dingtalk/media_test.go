@@ -0,0 +1,191 @@
+package dingtalk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestValidateMedia(t *testing.T) {
+	tests := []struct {
+		name      string
+		mediaType MediaType
+		filename  string
+		r         io.Reader
+		tooLarge  bool
+		wantErr   bool
+	}{
+		{"image", MediaTypeImage, "a.PNG", strings.NewReader("png"), false, false},
+		{"unknown type", MediaType("doc"), "a.pdf", strings.NewReader("pdf"), false, true},
+		{"unsupported extension", MediaTypeVoice, "a.ogg", strings.NewReader("ogg"), false, true},
+		{"sized reader too large", MediaTypeVoice, "a.mp3", bytes.NewReader(make([]byte, 2<<20+1)), true, true},
+		// 无法提前知道大小的 reader 在上传时检查
+		{"unsized reader", MediaTypeVoice, "a.mp3", io.LimitReader(zeroReader{}, 2<<20+1), false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := validateMedia(tt.mediaType, tt.filename, tt.r)
+			if (err != nil) != tt.wantErr || errors.Is(err, ErrMediaTooLarge) != tt.tooLarge {
+				t.Errorf("validateMedia() error = %v, wantErr %v, tooLarge %v", err, tt.wantErr, tt.tooLarge)
+			}
+		})
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
+
+// mediaServer 模拟 /media/upload，第一次上传返回 token 过期，记录每次收到的文件内容
+type mediaServer struct {
+	tokens  int32
+	uploads [][]byte
+	expire  bool
+}
+
+func (m *mediaServer) handle(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			atomic.AddInt32(&m.tokens, 1)
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/media/upload":
+			if r.URL.Query().Get("type") != "image" || r.URL.Query().Get("access_token") != "app-token" {
+				t.Errorf("upload query = %s", r.URL.RawQuery)
+			}
+			file, header, err := r.FormFile("media")
+			if err != nil {
+				// 超过大小限制时请求体被中断
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			content, _ := io.ReadAll(file)
+			if header.Filename != "logo.png" {
+				t.Errorf("filename = %q", header.Filename)
+			}
+			m.uploads = append(m.uploads, content)
+			if m.expire {
+				m.expire = false
+				_, _ = w.Write([]byte(`{"errcode":42001,"errmsg":"access_token expired"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"errcode":0,"type":"image","media_id":"@media-1","created_at":1709258400000}`))
+		}
+	}
+}
+
+func TestUploadMedia(t *testing.T) {
+	server := &mediaServer{expire: true}
+	client := newTestClient(t, server.handle(t))
+	observer := &recordObserver{}
+	client.observers = append(client.observers, observer)
+	ctx := context.Background()
+
+	// bytes.Reader 可以重放，token 过期后刷新 token 并重新上传完整内容
+	content := bytes.Repeat([]byte("0123456789"), 100000)
+	resp, err := client.UploadMedia(ctx, MediaTypeImage, "/tmp/logo.png", bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("UploadMedia() error = %v", err)
+	}
+	if resp.MediaID != "@media-1" || resp.CreatedAt.IsZero() {
+		t.Errorf("UploadMedia() = %+v", resp)
+	}
+	if len(server.uploads) != 2 || !bytes.Equal(server.uploads[0], content) || !bytes.Equal(server.uploads[1], content) {
+		t.Errorf("uploads = %d", len(server.uploads))
+	}
+	if n := atomic.LoadInt32(&server.tokens); n != 2 {
+		t.Errorf("gettoken calls = %d, want 2", n)
+	}
+	last := observer.calls[len(observer.calls)-1]
+	if last.Endpoint != "/media/upload" || last.Attempts != 2 || last.Err != nil {
+		t.Errorf("observed call = %+v", last)
+	}
+
+	// 不能重放的 reader 只上传一次
+	server.expire = true
+	server.uploads = nil
+	_, err = client.UploadMedia(ctx, MediaTypeImage, "logo.png", io.MultiReader(bytes.NewReader(content)))
+	if ErrorKindOf(err) != ErrorKindAuth || len(server.uploads) != 1 {
+		t.Errorf("UploadMedia(unseekable) error = %v, uploads = %d", err, len(server.uploads))
+	}
+
+	_, err = client.UploadMedia(ctx, MediaTypeImage, "logo.png", io.LimitReader(zeroReader{}, 20<<20+1))
+	if !errors.Is(err, ErrMediaTooLarge) {
+		t.Errorf("UploadMedia(too large) error = %v, want ErrMediaTooLarge", err)
+	}
+}
+
+func TestDownloadMedia(t *testing.T) {
+	// 下载的文件本身是超过错误信息读取长度的 JSON 时内容完整
+	largeJSON := `{"items":"` + strings.Repeat("x", 100000) + `"}`
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/media/downloadFile":
+			if r.URL.Query().Get("media_id") == "@json" {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(largeJSON))
+				return
+			}
+			if r.URL.Query().Get("media_id") != "@media-1" {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"errcode":40004,"errmsg":"invalid media_id"}`))
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte("png-content"))
+		case "/v1.0/robot/messageFiles/download":
+			_, _ = w.Write([]byte(`{"downloadUrl":"https://files.example.com/robot/abc?sig=s1"}`))
+		case "/robot/abc":
+			if r.URL.Query().Get("sig") != "s1" || r.URL.Query().Get("access_token") != "" ||
+				r.Header.Get("x-acs-dingtalk-access-token") != "" {
+				t.Errorf("download request = %s %v", r.URL, r.Header)
+			}
+			_, _ = w.Write([]byte("robot-file"))
+		}
+	})
+	ctx := context.Background()
+
+	body, err := client.DownloadMedia(ctx, "@media-1")
+	if err != nil {
+		t.Fatalf("DownloadMedia() error = %v", err)
+	}
+	content, _ := io.ReadAll(body)
+	_ = body.Close()
+	if string(content) != "png-content" {
+		t.Errorf("DownloadMedia() content = %q", content)
+	}
+	if _, err := client.DownloadMedia(ctx, "missing"); err == nil || !strings.Contains(err.Error(), "40004") {
+		t.Errorf("DownloadMedia(missing) error = %v", err)
+	}
+	body, err = client.DownloadMedia(ctx, "@json")
+	if err != nil {
+		t.Fatalf("DownloadMedia(json) error = %v", err)
+	}
+	content, _ = io.ReadAll(body)
+	_ = body.Close()
+	if string(content) != largeJSON {
+		t.Errorf("DownloadMedia(json) content length = %d, want %d", len(content), len(largeJSON))
+	}
+
+	body, err = client.DownloadRobotMessageFile(ctx, "dc1", "robot1")
+	if err != nil {
+		t.Fatalf("DownloadRobotMessageFile() error = %v", err)
+	}
+	content, _ = io.ReadAll(body)
+	_ = body.Close()
+	if string(content) != "robot-file" {
+		t.Errorf("DownloadRobotMessageFile() content = %q", content)
+	}
+}
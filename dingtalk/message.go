@@ -0,0 +1,41 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+// SendWorkNotification 以应用身份发送工作通知，req.AgentID 为 0 时使用 Client.AgentID，返回异步任务 ID
+func (c *Client) SendWorkNotification(ctx context.Context, req *models.SendWorkNotificationRequest) (int64, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/asynchronous-sending-of-enterprise-session-messages
+	if req == nil || (req.UserIdList == "" && req.DeptIdList == "" && !req.ToAllUser) {
+		return 0, errors.New("dingtalk.SendWorkNotification, receivers are required")
+	}
+	if req.AgentID == 0 {
+		agentId, err := strconv.ParseInt(c.AgentID, 10, 64)
+		if err != nil {
+			return 0, errors.New("dingtalk.SendWorkNotification, AgentID is required")
+		}
+		req.AgentID = agentId
+	}
+	resp := &models.SendWorkNotificationResponse{}
+	if err := c.doTopApi(ctx, "/topapi/message/corpconversation/asyncsend_v2", req, resp); err != nil {
+		return 0, err
+	}
+	return resp.TaskID, nil
+}
+
+// SendMarkdownWorkNotification 向 userIds 发送 markdown 工作通知
+func (c *Client) SendMarkdownWorkNotification(ctx context.Context, userIds []string, title, text string) (int64, error) {
+	return c.SendWorkNotification(ctx, &models.SendWorkNotificationRequest{
+		UserIdList: strings.Join(userIds, ","),
+		Msg: models.WorkNotificationMessage{
+			MsgType:  "markdown",
+			Markdown: &models.WorkNotificationMarkdown{Title: title, Text: text},
+		},
+	})
+}
@@ -0,0 +1,216 @@
+package dingtalk
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLatencyBuckets 接口耗时直方图的默认分桶，单位为秒
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type requestKey struct {
+	endpoint string
+	method   string
+	status   int
+	code     string
+}
+
+type seriesKey struct {
+	endpoint string
+	method   string
+}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// PrometheusObserver 统计调用次数、耗时、重试和 token 缓存命中，以 Prometheus 文本格式输出，
+// 可以直接注册为 /metrics 的 http.Handler
+//
+//	metrics := dingtalk.NewPrometheusObserver()
+//	client := dingtalk.NewDingTalkClient(id, secret, dingtalk.WithObserver(metrics))
+//	http.Handle("/metrics", metrics)
+type PrometheusObserver struct {
+	buckets []float64
+
+	mutex     sync.Mutex
+	requests  map[requestKey]uint64
+	retries   map[seriesKey]uint64
+	latencies map[seriesKey]*histogram
+	tokenHit  uint64
+	tokenMiss uint64
+}
+
+// NewPrometheusObserver 创建指标统计，buckets 为空时使用 DefaultLatencyBuckets
+func NewPrometheusObserver(buckets ...float64) *PrometheusObserver {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &PrometheusObserver{
+		buckets:   buckets,
+		requests:  map[requestKey]uint64{},
+		retries:   map[seriesKey]uint64{},
+		latencies: map[seriesKey]*histogram{},
+	}
+}
+
+func (p *PrometheusObserver) CallStarted(ctx context.Context, ep Endpoint) context.Context {
+	return ctx
+}
+
+func (p *PrometheusObserver) CallFinished(ctx context.Context, info CallInfo) {
+	key := seriesKey{endpoint: info.Endpoint, method: info.Method}
+	seconds := info.Latency.Seconds()
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.requests[requestKey{endpoint: info.Endpoint, method: info.Method, status: info.StatusCode, code: info.Code}]++
+	p.retries[key] += uint64(info.Retries())
+	h, ok := p.latencies[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(p.buckets))}
+		p.latencies[key] = h
+	}
+	for i, bound := range p.buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+}
+
+func (p *PrometheusObserver) TokenCache(hit bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if hit {
+		p.tokenHit++
+	} else {
+		p.tokenMiss++
+	}
+}
+
+// ServeHTTP 输出 Prometheus 文本格式的指标
+func (p *PrometheusObserver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = p.WriteTo(w)
+}
+
+// WriteTo 将指标以 Prometheus 文本格式写入 w
+func (p *PrometheusObserver) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	p.mutex.Lock()
+	p.writeRequests(cw)
+	p.writeLatencies(cw)
+	p.writeRetries(cw)
+	p.writeTokenCache(cw)
+	p.mutex.Unlock()
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+func (p *PrometheusObserver) writeRequests(w *countingWriter) {
+	w.printf("# HELP dingtalk_requests_total Total number of DingTalk API calls.\n")
+	w.printf("# TYPE dingtalk_requests_total counter\n")
+	keys := make([]requestKey, 0, len(p.requests))
+	for k := range p.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.endpoint != b.endpoint {
+			return a.endpoint < b.endpoint
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		if a.status != b.status {
+			return a.status < b.status
+		}
+		return a.code < b.code
+	})
+	for _, k := range keys {
+		w.printf("dingtalk_requests_total{endpoint=%s,method=%s,status=\"%d\",code=%s} %d\n",
+			quoteLabel(k.endpoint), quoteLabel(k.method), k.status, quoteLabel(k.code), p.requests[k])
+	}
+}
+
+func (p *PrometheusObserver) writeLatencies(w *countingWriter) {
+	w.printf("# HELP dingtalk_request_duration_seconds DingTalk API call latency including retries.\n")
+	w.printf("# TYPE dingtalk_request_duration_seconds histogram\n")
+	for _, k := range sortedSeriesKeys(p.latencies) {
+		h := p.latencies[k]
+		labels := "endpoint=" + quoteLabel(k.endpoint) + ",method=" + quoteLabel(k.method)
+		for i, bound := range p.buckets {
+			w.printf("dingtalk_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n",
+				labels, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		w.printf("dingtalk_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		w.printf("dingtalk_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		w.printf("dingtalk_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+}
+
+func (p *PrometheusObserver) writeRetries(w *countingWriter) {
+	w.printf("# HELP dingtalk_request_retries_total Total number of DingTalk API retries.\n")
+	w.printf("# TYPE dingtalk_request_retries_total counter\n")
+	for _, k := range sortedSeriesKeys(p.retries) {
+		w.printf("dingtalk_request_retries_total{endpoint=%s,method=%s} %d\n",
+			quoteLabel(k.endpoint), quoteLabel(k.method), p.retries[k])
+	}
+}
+
+func (p *PrometheusObserver) writeTokenCache(w *countingWriter) {
+	w.printf("# HELP dingtalk_token_cache_total GetAccessToken cache hits and misses.\n")
+	w.printf("# TYPE dingtalk_token_cache_total counter\n")
+	w.printf("dingtalk_token_cache_total{result=\"hit\"} %d\n", p.tokenHit)
+	w.printf("dingtalk_token_cache_total{result=\"miss\"} %d\n", p.tokenMiss)
+}
+
+func sortedSeriesKeys[V any](m map[seriesKey]V) []seriesKey {
+	keys := make([]seriesKey, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		return keys[i].method < keys[j].method
+	})
+	return keys
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabel(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
+
+// countingWriter 记录写入的字节数和第一个错误
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (w *countingWriter) printf(format string, args ...interface{}) {
+	if w.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(w.w, format, args...)
+	w.n += int64(n)
+	w.err = err
+}
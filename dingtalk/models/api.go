@@ -6,3 +6,9 @@ type TopResult[T any] struct {
 	Result       T      `json:"result"`
 	RequestID    string `json:"request_id"`
 }
+
+type OpenApiErrorResponse struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestid"`
+}
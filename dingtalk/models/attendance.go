@@ -0,0 +1,141 @@
+package models
+
+type ListPunchRecordsRequest struct {
+	UserIds       []string `json:"userIds"`
+	CheckDateFrom string   `json:"checkDateFrom"`
+	CheckDateTo   string   `json:"checkDateTo"`
+	IsI18n        bool     `json:"isI18n"`
+}
+
+// PunchRecord 打卡详情
+type PunchRecord struct {
+	ID             int64       `json:"id"`
+	UserID         string      `json:"userId"`
+	CorpID         string      `json:"corpId"`
+	GroupID        int64       `json:"groupId"`
+	PlanID         int64       `json:"planId"`
+	ClassID        int64       `json:"classId"`
+	WorkDate       EpochMillis `json:"workDate"`
+	CheckType      string      `json:"checkType"`
+	SourceType     string      `json:"sourceType"`
+	TimeResult     string      `json:"timeResult"`
+	LocationResult string      `json:"locationResult"`
+	LocationMethod string      `json:"locationMethod"`
+	BaseCheckTime  EpochMillis `json:"baseCheckTime"`
+	UserCheckTime  EpochMillis `json:"userCheckTime"`
+	UserAddress    string      `json:"userAddress"`
+	UserLatitude   float64     `json:"userLatitude"`
+	UserLongitude  float64     `json:"userLongitude"`
+	DeviceID       string      `json:"deviceId"`
+	IsLegal        string      `json:"isLegal"`
+	ProcInstID     string      `json:"procInstId"`
+	ApproveID      int64       `json:"approveId"`
+}
+
+type ListPunchRecordsResponse struct {
+	RecordResult []PunchRecord `json:"recordresult"`
+}
+
+type AttendanceCheckTime struct {
+	CheckTime string `json:"check_time"`
+	CheckType string `json:"check_type"`
+	Across    int    `json:"across"`
+}
+
+type AttendanceSection struct {
+	Times []AttendanceCheckTime `json:"times"`
+}
+
+type AttendanceClass struct {
+	ClassID  int64               `json:"class_id"`
+	Name     string              `json:"name"`
+	Sections []AttendanceSection `json:"sections"`
+}
+
+// UserAttendanceGroup 用户所在的考勤组，Type 为 FIXED(固定班制)、TURN(排班制)、NONE(自由工时)
+type UserAttendanceGroup struct {
+	GroupID int64             `json:"group_id"`
+	Name    string            `json:"name"`
+	Type    string            `json:"type"`
+	Classes []AttendanceClass `json:"classes"`
+}
+
+type AttendanceSelectedClass struct {
+	ClassID   int64               `json:"class_id"`
+	ClassName string              `json:"class_name"`
+	Sections  []AttendanceSection `json:"sections"`
+}
+
+type AttendanceGroup struct {
+	GroupID       int64                     `json:"group_id"`
+	GroupName     string                    `json:"group_name"`
+	Type          string                    `json:"type"`
+	MemberCount   int                       `json:"member_count"`
+	ManagerList   []string                  `json:"manager_list"`
+	DeptNameList  []string                  `json:"dept_name_list"`
+	ClassesList   []string                  `json:"classes_list"`
+	SelectedClass []AttendanceSelectedClass `json:"selected_class"`
+}
+
+type ListAttendanceGroupsResponse struct {
+	Groups  []AttendanceGroup `json:"groups"`
+	HasMore bool              `json:"has_more"`
+}
+
+type AttendancePunch struct {
+	CheckType string `json:"check_type"`
+	CheckTime string `json:"check_time"`
+	Across    int    `json:"across"`
+}
+
+type AttendanceShiftSection struct {
+	Punches []AttendancePunch `json:"punches"`
+}
+
+type AttendanceShiftSetting struct {
+	WorkTimeMinutes int `json:"work_time_minutes"`
+}
+
+type AttendanceShift struct {
+	ID             int64                    `json:"id"`
+	Name           string                   `json:"name"`
+	Owner          string                   `json:"owner"`
+	CorpID         string                   `json:"corp_id"`
+	ShiftGroupID   int64                    `json:"shift_group_id"`
+	ShiftGroupName string                   `json:"shift_group_name"`
+	Sections       []AttendanceShiftSection `json:"sections"`
+	ShiftSetting   AttendanceShiftSetting   `json:"shift_setting"`
+}
+
+// LeaveStatus 请假状态，DurationUnit 为 percent_day 或 percent_hour，DurationPercent 为时长 * 100
+type LeaveStatus struct {
+	UserID          string      `json:"userid"`
+	StartTime       EpochMillis `json:"start_time"`
+	EndTime         EpochMillis `json:"end_time"`
+	DurationUnit    string      `json:"duration_unit"`
+	DurationPercent int64       `json:"duration_percent"`
+}
+
+type GetLeaveStatusResponse struct {
+	LeaveStatus []LeaveStatus `json:"leave_status"`
+	HasMore     bool          `json:"has_more"`
+}
+
+// LeaveQuota 假期余额，数量字段为实际值 * 100
+type LeaveQuota struct {
+	QuotaID         string      `json:"quota_id"`
+	UserID          string      `json:"userid"`
+	LeaveCode       string      `json:"leave_code"`
+	QuotaCycle      string      `json:"quota_cycle"`
+	StartTime       EpochMillis `json:"start_time"`
+	EndTime         EpochMillis `json:"end_time"`
+	QuotaNumPerDay  int64       `json:"quota_num_per_day"`
+	QuotaNumPerHour int64       `json:"quota_num_per_hour"`
+	UsedNumPerDay   int64       `json:"used_num_per_day"`
+	UsedNumPerHour  int64       `json:"used_num_per_hour"`
+}
+
+type ListLeaveQuotasResponse struct {
+	LeaveQuotas []LeaveQuota `json:"leave_quotas"`
+	HasMore     bool         `json:"has_more"`
+}
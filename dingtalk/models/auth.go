@@ -22,3 +22,19 @@ type GetTokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	ExpiresIn    int    `json:"expires_in"`
 }
+
+type GetJsapiTicketResponse struct {
+	ErrorCode    int    `json:"errcode"`
+	ErrorMessage string `json:"errmsg"`
+	Ticket       string `json:"ticket"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// JsapiConfig 为前端 dd.config 所需的参数
+type JsapiConfig struct {
+	AgentID   string `json:"agentId"`
+	CorpID    string `json:"corpId"`
+	TimeStamp int64  `json:"timeStamp"`
+	NonceStr  string `json:"nonceStr"`
+	Signature string `json:"signature"`
+}
@@ -19,3 +19,42 @@ type CalendarResponse struct {
 type CalendarOriginResponse struct {
 	Calendars CalendarList `json:"calendars"`
 }
+
+// CreateEventTime 创建日程时的时间，全天日程设置 Date（2006-01-02），否则设置 DateTime（RFC3339）
+type CreateEventTime struct {
+	Date     string `json:"date,omitempty"`
+	DateTime string `json:"dateTime,omitempty"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+type CreateEventAttendee struct {
+	ID         string `json:"id"`
+	IsOptional bool   `json:"isOptional,omitempty"`
+}
+
+type CreateEventLocation struct {
+	DisplayName string `json:"displayName"`
+}
+
+type CreateEventReminder struct {
+	Method  string `json:"method"`
+	Minutes int    `json:"minutes"`
+}
+
+// CreateEventOnlineMeeting 创建日程时同时创建线上会议，Type 目前仅支持 dingtalk
+type CreateEventOnlineMeeting struct {
+	Type string `json:"type"`
+}
+
+type CreateCalendarEventRequest struct {
+	Summary           string                    `json:"summary"`
+	Description       string                    `json:"description,omitempty"`
+	Start             CreateEventTime           `json:"start"`
+	End               CreateEventTime           `json:"end"`
+	IsAllDay          bool                      `json:"isAllDay,omitempty"`
+	Recurrence        *EventRecurrence          `json:"recurrence,omitempty"`
+	Attendees         []CreateEventAttendee     `json:"attendees,omitempty"`
+	Location          *CreateEventLocation      `json:"location,omitempty"`
+	Reminders         []CreateEventReminder     `json:"reminders,omitempty"`
+	OnlineMeetingInfo *CreateEventOnlineMeeting `json:"onlineMeetingInfo,omitempty"`
+}
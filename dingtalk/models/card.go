@@ -0,0 +1,118 @@
+package models
+
+type CardData struct {
+	CardParamMap map[string]string `json:"cardParamMap"`
+}
+
+type CardImGroupOpenSpaceModel struct {
+	SupportForward bool `json:"supportForward"`
+}
+
+type CardImGroupOpenDeliverModel struct {
+	RobotCode  string            `json:"robotCode,omitempty"`
+	AtUserIds  map[string]string `json:"atUserIds,omitempty"`
+	Recipients []string          `json:"recipients,omitempty"`
+}
+
+type CardImRobotOpenSpaceModel struct {
+	SupportForward bool `json:"supportForward"`
+}
+
+type CardImRobotOpenDeliverModel struct {
+	SpaceType string `json:"spaceType"`
+	RobotCode string `json:"robotCode,omitempty"`
+}
+
+type CreateAndDeliverCardRequest struct {
+	CardTemplateID          string                       `json:"cardTemplateId"`
+	OutTrackID              string                       `json:"outTrackId"`
+	CallbackType            string                       `json:"callbackType,omitempty"`
+	CallbackRouteKey        string                       `json:"callbackRouteKey,omitempty"`
+	CardData                CardData                     `json:"cardData"`
+	PrivateData             map[string]CardData          `json:"privateData,omitempty"`
+	OpenSpaceID             string                       `json:"openSpaceId"`
+	UserIdType              int                          `json:"userIdType,omitempty"`
+	ImGroupOpenSpaceModel   *CardImGroupOpenSpaceModel   `json:"imGroupOpenSpaceModel,omitempty"`
+	ImGroupOpenDeliverModel *CardImGroupOpenDeliverModel `json:"imGroupOpenDeliverModel,omitempty"`
+	ImRobotOpenSpaceModel   *CardImRobotOpenSpaceModel   `json:"imRobotOpenSpaceModel,omitempty"`
+	ImRobotOpenDeliverModel *CardImRobotOpenDeliverModel `json:"imRobotOpenDeliverModel,omitempty"`
+}
+
+type CardDeliverResult struct {
+	SpaceType string `json:"spaceType"`
+	SpaceID   string `json:"spaceId"`
+	Success   bool   `json:"success"`
+	ErrorMsg  string `json:"errorMsg"`
+	CarrierID string `json:"carrierId"`
+}
+
+type CreateAndDeliverCardResult struct {
+	OutTrackID     string              `json:"outTrackId"`
+	DeliverResults []CardDeliverResult `json:"deliverResults"`
+}
+
+type CreateAndDeliverCardResponse struct {
+	Success bool                       `json:"success"`
+	Result  CreateAndDeliverCardResult `json:"result"`
+}
+
+type CardUpdateOptions struct {
+	UpdateCardDataByKey    bool `json:"updateCardDataByKey"`
+	UpdatePrivateDataByKey bool `json:"updatePrivateDataByKey"`
+}
+
+type UpdateCardRequest struct {
+	OutTrackID        string              `json:"outTrackId"`
+	CardData          *CardData           `json:"cardData,omitempty"`
+	PrivateData       map[string]CardData `json:"privateData,omitempty"`
+	CardUpdateOptions *CardUpdateOptions  `json:"cardUpdateOptions,omitempty"`
+	UserIdType        int                 `json:"userIdType,omitempty"`
+}
+
+type UpdateCardResponse struct {
+	Success bool `json:"success"`
+}
+
+type StreamingUpdateCardRequest struct {
+	OutTrackID string `json:"outTrackId"`
+	GUID       string `json:"guid"`
+	Key        string `json:"key"`
+	Content    string `json:"content"`
+	IsFull     bool   `json:"isFull"`
+	IsFinalize bool   `json:"isFinalize"`
+	IsError    bool   `json:"isError"`
+}
+
+type StreamingUpdateCardResponse struct {
+	Success bool `json:"success"`
+}
+
+// CardCallbackRequest 卡片回传请求，HTTP 回调和 Stream 模式下的结构一致
+type CardCallbackRequest struct {
+	Type       string `json:"type"`
+	OutTrackID string `json:"outTrackId"`
+	CorpID     string `json:"corpId"`
+	UserID     string `json:"userId"`
+	UserIdType int    `json:"userIdType"`
+	SpaceType  string `json:"spaceType"`
+	SpaceID    string `json:"spaceId"`
+	Content    string `json:"content"`
+	Value      string `json:"value"`
+	Extension  string `json:"extension"`
+}
+
+// CardCallbackContent 为 CardCallbackRequest.Content 解码后的内容
+type CardCallbackContent struct {
+	CardPrivateData CardCallbackPrivateData `json:"cardPrivateData"`
+}
+
+type CardCallbackPrivateData struct {
+	ActionIDs []string               `json:"actionIds"`
+	Params    map[string]interface{} `json:"params"`
+}
+
+type CardCallbackResponse struct {
+	CardData          *CardData           `json:"cardData,omitempty"`
+	PrivateData       map[string]CardData `json:"privateData,omitempty"`
+	CardUpdateOptions *CardUpdateOptions  `json:"cardUpdateOptions,omitempty"`
+}
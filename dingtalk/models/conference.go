@@ -0,0 +1,73 @@
+package models
+
+type CreateVideoConferenceRequest struct {
+	UserID        string   `json:"userId"`
+	ConfTitle     string   `json:"confTitle"`
+	InviteUserIds []string `json:"inviteUserIds,omitempty"`
+	InviteCaller  bool     `json:"inviteCaller,omitempty"`
+}
+
+// VideoConference 即时视频会议，ExternalLinkURL 为入会链接
+type VideoConference struct {
+	ConferenceID       string   `json:"conferenceId"`
+	ConferencePassword string   `json:"conferencePassword"`
+	HostPassword       string   `json:"hostPassword"`
+	ExternalLinkURL    string   `json:"externalLinkUrl"`
+	PhoneNumbers       []string `json:"phoneNumbers"`
+}
+
+type CreateScheduleConferenceRequest struct {
+	CreatorUnionID string      `json:"creatorUnionId"`
+	Title          string      `json:"title"`
+	StartTime      EpochMillis `json:"startTime"`
+	EndTime        EpochMillis `json:"endTime"`
+}
+
+// ScheduleConference 预约会议，URL 为入会链接
+type ScheduleConference struct {
+	ScheduleConferenceID string   `json:"scheduleConferenceId"`
+	URL                  string   `json:"url"`
+	RoomCode             string   `json:"roomCode"`
+	Phones               []string `json:"phones"`
+}
+
+type CancelScheduleConferenceRequest struct {
+	CreatorUnionID       string `json:"creatorUnionId"`
+	ScheduleConferenceID string `json:"scheduleConferenceId"`
+}
+
+type VideoConferenceInfo struct {
+	ConferenceID    string      `json:"conferenceId"`
+	Title           string      `json:"title"`
+	RoomCode        string      `json:"roomCode"`
+	Status          FlexInt     `json:"status"`
+	StartTime       EpochMillis `json:"startTime"`
+	EndTime         EpochMillis `json:"endTime"`
+	CreatorID       string      `json:"creatorId"`
+	CreatorNick     string      `json:"creatorNick"`
+	ExternalLinkURL string      `json:"externalLinkUrl"`
+	UserCount       FlexInt     `json:"userCount"`
+	AttendUserCount FlexInt     `json:"attendUserCount"`
+}
+
+type GetVideoConferenceResponse struct {
+	ConfInfo VideoConferenceInfo `json:"confInfo"`
+}
+
+// ConferenceMember 会议参与人，Duration 为参会时长（毫秒）
+type ConferenceMember struct {
+	UnionID   string      `json:"unionId"`
+	UserID    string      `json:"userId"`
+	UserNick  string      `json:"userNick"`
+	JoinTime  EpochMillis `json:"joinTime"`
+	LeaveTime EpochMillis `json:"leaveTime"`
+	Duration  FlexInt     `json:"duration"`
+	Host      bool        `json:"host"`
+	Attended  bool        `json:"attended"`
+}
+
+type ListConferenceMembersResponse struct {
+	MemberModels []ConferenceMember `json:"memberModels"`
+	NextToken    string             `json:"nextToken"`
+	TotalCount   FlexInt            `json:"totalCount"`
+}
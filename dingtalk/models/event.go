@@ -1,11 +1,9 @@
 package models
 
-import "time"
-
 type EventTime struct {
-	Date     string    `json:"date"`
-	DateTime time.Time `json:"dateTime"`
-	TimeZone string    `json:"timeZone"`
+	Date     Date   `json:"date"`
+	DateTime Time   `json:"dateTime"`
+	TimeZone string `json:"timeZone"`
 }
 
 type EventRecurrencePattern struct {
@@ -17,9 +15,9 @@ type EventRecurrencePattern struct {
 }
 
 type EventRecurrenceRange struct {
-	Type                string    `json:"type"`
-	EndDate             time.Time `json:"endDate"`
-	NumberOfOccurrences int       `json:"numberOfOccurrences"`
+	Type                string `json:"type"`
+	EndDate             Date   `json:"endDate"`
+	NumberOfOccurrences int    `json:"numberOfOccurrences"`
 }
 
 type EventRecurrence struct {
@@ -55,8 +53,8 @@ type EventOnlineMeetingInfo struct {
 }
 
 type EventReminder struct {
-	Method  string `json:"method"`
-	Minutes string `json:"minutes"`
+	Method  string  `json:"method"`
+	Minutes FlexInt `json:"minutes"`
 }
 type EventReminderList []EventReminder
 
@@ -93,8 +91,8 @@ type CalendarEvent struct {
 	Organizer          EventOrganizer          `json:"organizer"`
 	Location           EventLocation           `json:"location"`
 	SeriesMasterId     string                  `json:"seriesMasterId"`
-	CreateTime         time.Time               `json:"createTime"`
-	UpdateTime         time.Time               `json:"updateTime"`
+	CreateTime         Time                    `json:"createTime"`
+	UpdateTime         Time                    `json:"updateTime"`
 	Status             string                  `json:"status"`
 	OnlineMeetingInfo  EventOnlineMeetingInfo  `json:"onlineMeetingInfo"`
 	Reminders          EventReminderList       `json:"reminders"`
@@ -0,0 +1,57 @@
+package models
+
+type GetSuiteTokenRequest struct {
+	SuiteKey    string `json:"suite_key"`
+	SuiteSecret string `json:"suite_secret"`
+	SuiteTicket string `json:"suite_ticket"`
+}
+
+type GetSuiteTokenResponse struct {
+	ErrorCode        int    `json:"errcode"`
+	ErrorMessage     string `json:"errmsg"`
+	SuiteAccessToken string `json:"suite_access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+}
+
+type AuthCorpRequest struct {
+	AuthCorpID string `json:"auth_corpid"`
+}
+
+type AuthCorpInfo struct {
+	CorpID          string `json:"corpid"`
+	CorpName        string `json:"corp_name"`
+	CorpLogoURL     string `json:"corp_logo_url"`
+	Industry        string `json:"industry"`
+	IsAuthenticated bool   `json:"is_authenticated"`
+	AuthLevel       int    `json:"auth_level"`
+	AuthChannel     string `json:"auth_channel"`
+	AuthChannelType string `json:"auth_channel_type"`
+	InviteCode      string `json:"invite_code"`
+	LicenseCode     string `json:"license_code"`
+	CorpProvince    string `json:"corp_province"`
+	CorpCity        string `json:"corp_city"`
+}
+
+type AuthUserInfo struct {
+	UserID string `json:"userId"`
+}
+
+type AuthAgent struct {
+	AgentID   int64    `json:"agentid"`
+	AgentName string   `json:"agent_name"`
+	LogoURL   string   `json:"logo_url"`
+	AppID     int64    `json:"appid"`
+	AdminList []string `json:"admin_list"`
+}
+
+type AuthInfo struct {
+	Agent []AuthAgent `json:"agent"`
+}
+
+type GetAuthInfoResponse struct {
+	ErrorCode    int          `json:"errcode"`
+	ErrorMessage string       `json:"errmsg"`
+	AuthCorpInfo AuthCorpInfo `json:"auth_corp_info"`
+	AuthUserInfo AuthUserInfo `json:"auth_user_info"`
+	AuthInfo     AuthInfo     `json:"auth_info"`
+}
@@ -0,0 +1,18 @@
+package models
+
+type UploadMediaResponse struct {
+	ErrorCode    int         `json:"errcode"`
+	ErrorMessage string      `json:"errmsg"`
+	Type         string      `json:"type"`
+	MediaID      string      `json:"media_id"`
+	CreatedAt    EpochMillis `json:"created_at"`
+}
+
+type RobotMessageFileDownloadRequest struct {
+	DownloadCode string `json:"downloadCode"`
+	RobotCode    string `json:"robotCode"`
+}
+
+type RobotMessageFileDownloadResponse struct {
+	DownloadURL string `json:"downloadUrl"`
+}
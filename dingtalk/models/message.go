@@ -0,0 +1,30 @@
+package models
+
+type WorkNotificationMarkdown struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+type WorkNotificationText struct {
+	Content string `json:"content"`
+}
+
+// WorkNotificationMessage 工作通知消息，MsgType 为 text 或 markdown
+type WorkNotificationMessage struct {
+	MsgType  string                    `json:"msgtype"`
+	Text     *WorkNotificationText     `json:"text,omitempty"`
+	Markdown *WorkNotificationMarkdown `json:"markdown,omitempty"`
+}
+
+// SendWorkNotificationRequest 发送工作通知，UserIdList、DeptIdList 为逗号分隔的列表
+type SendWorkNotificationRequest struct {
+	AgentID    int64                   `json:"agent_id"`
+	UserIdList string                  `json:"userid_list,omitempty"`
+	DeptIdList string                  `json:"dept_id_list,omitempty"`
+	ToAllUser  bool                    `json:"to_all_user,omitempty"`
+	Msg        WorkNotificationMessage `json:"msg"`
+}
+
+type SendWorkNotificationResponse struct {
+	TaskID int64 `json:"task_id"`
+}
@@ -0,0 +1,73 @@
+package models
+
+// CreateSceneGroupRequest 基于群模板创建场景群，UserIds、SubadminIds 为逗号分隔的 userId
+type CreateSceneGroupRequest struct {
+	Title               string `json:"title"`
+	TemplateID          string `json:"template_id"`
+	OwnerUserID         string `json:"owner_user_id"`
+	UserIds             string `json:"user_ids,omitempty"`
+	SubadminIds         string `json:"subadmin_ids,omitempty"`
+	UUID                string `json:"uuid,omitempty"`
+	Icon                string `json:"icon,omitempty"`
+	MentionAllAuthority int    `json:"mention_all_authority,omitempty"`
+	ShowHistoryType     int    `json:"show_history_type,omitempty"`
+	ValidationType      int    `json:"validation_type,omitempty"`
+	Searchable          int    `json:"searchable,omitempty"`
+	ChatBannedType      int    `json:"chat_banned_type,omitempty"`
+	ManagementType      int    `json:"management_type,omitempty"`
+}
+
+type CreateSceneGroupResponse struct {
+	OpenConversationID string `json:"open_conversation_id"`
+	ChatID             string `json:"chat_id"`
+}
+
+// CreateChatRequest 创建普通群
+type CreateChatRequest struct {
+	Name            string   `json:"name"`
+	Owner           string   `json:"owner"`
+	UserIdList      []string `json:"useridlist"`
+	ShowHistoryType int      `json:"showHistoryType,omitempty"`
+	Searchable      int      `json:"searchable,omitempty"`
+}
+
+type CreateChatResponse struct {
+	ChatID             string `json:"chatid"`
+	OpenConversationID string `json:"openConversationId"`
+	ConversationTag    int    `json:"conversationTag"`
+}
+
+type SceneGroupMembersRequest struct {
+	OpenConversationID string `json:"open_conversation_id"`
+	UserIds            string `json:"user_ids"`
+}
+
+// UpdateSceneGroupRequest 更新场景群，只会修改非空字段
+type UpdateSceneGroupRequest struct {
+	OpenConversationID string `json:"open_conversation_id"`
+	Title              string `json:"title,omitempty"`
+	OwnerUserID        string `json:"owner_user_id,omitempty"`
+	Icon               string `json:"icon,omitempty"`
+}
+
+type SceneGroup struct {
+	OpenConversationID  string  `json:"open_conversation_id"`
+	TemplateID          string  `json:"template_id"`
+	Title               string  `json:"title"`
+	OwnerUserID         string  `json:"owner_user_id"`
+	Icon                string  `json:"icon"`
+	GroupURL            string  `json:"group_url"`
+	Status              FlexInt `json:"status"`
+	MentionAllAuthority FlexInt `json:"mention_all_authority"`
+	ShowHistoryType     FlexInt `json:"show_history_type"`
+	ValidationType      FlexInt `json:"validation_type"`
+	Searchable          FlexInt `json:"searchable"`
+	ChatBannedType      FlexInt `json:"chat_banned_type"`
+	ManagementType      FlexInt `json:"management_type"`
+}
+
+// InstallGroupRobotRequest 将机器人添加到群，RobotCode 为机器人编码
+type InstallGroupRobotRequest struct {
+	RobotCode          string `json:"robotCode"`
+	OpenConversationID string `json:"openConversationId"`
+}
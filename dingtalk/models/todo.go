@@ -8,7 +8,7 @@ type CreateTodoTaskRequestNotifyConfigs struct {
 	DingNotify string `json:"dingNotify"`
 }
 type CreateTodoTaskRequest struct {
-	SourceID           string                         `json:"sourceId"`
+	SourceID           string                         `json:"sourceId,omitempty"`
 	Subject            string                         `json:"subject"`
 	CreatorID          string                         `json:"creatorId"`
 	Description        string                         `json:"description"`
@@ -22,21 +22,71 @@ type CreateTodoTaskRequest struct {
 }
 
 type CreateTodoTaskResponse struct {
-	ID             string   `json:"id"`
-	BizTag         string   `json:"bizTag"`
-	CreatedTime    int64    `json:"createdTime"`
-	CreatorID      string   `json:"creatorId"`
-	Done           bool     `json:"done"`
-	DueTime        int64    `json:"dueTime"`
-	FinishTime     int      `json:"finishTime"`
-	ModifiedTime   int64    `json:"modifiedTime"`
-	ModifierId     string   `json:"modifierId"`
-	ParticipantIds []string `json:"participantIds"`
-	Priority       int      `json:"priority"`
-	RequestId      string   `json:"requestId"`
-	Source         string   `json:"source"`
-	StartTime      int      `json:"startTime"`
-	Subject        string   `json:"subject"`
-	TenantId       string   `json:"tenantId"`
-	TenantType     string   `json:"tenantType"`
+	ID             string      `json:"id"`
+	BizTag         string      `json:"bizTag"`
+	CreatedTime    EpochMillis `json:"createdTime"`
+	CreatorID      string      `json:"creatorId"`
+	Done           bool        `json:"done"`
+	DueTime        EpochMillis `json:"dueTime"`
+	FinishTime     EpochMillis `json:"finishTime"`
+	ModifiedTime   EpochMillis `json:"modifiedTime"`
+	ModifierId     string      `json:"modifierId"`
+	ParticipantIds []string    `json:"participantIds"`
+	Priority       int         `json:"priority"`
+	RequestId      string      `json:"requestId"`
+	Source         string      `json:"source"`
+	StartTime      EpochMillis `json:"startTime"`
+	Subject        string      `json:"subject"`
+	TenantId       string      `json:"tenantId"`
+	TenantType     string      `json:"tenantType"`
+}
+
+// TodoTask 待办详情
+type TodoTask struct {
+	ID             string                         `json:"id"`
+	Subject        string                         `json:"subject"`
+	Description    string                         `json:"description"`
+	SourceID       string                         `json:"sourceId"`
+	CreatorID      string                         `json:"creatorId"`
+	ExecutorIds    []string                       `json:"executorIds"`
+	ParticipantIds []string                       `json:"participantIds"`
+	DueTime        EpochMillis                    `json:"dueTime"`
+	CreatedTime    EpochMillis                    `json:"createdTime"`
+	ModifiedTime   EpochMillis                    `json:"modifiedTime"`
+	FinishTime     EpochMillis                    `json:"finishTime"`
+	Done           bool                           `json:"done"`
+	Priority       FlexInt                        `json:"priority"`
+	BizTag         string                         `json:"bizTag"`
+	DetailUrl      CreateTodoTaskRequestDetailUrl `json:"detailUrl"`
+}
+
+type QueryTodoTasksRequest struct {
+	NextToken string `json:"nextToken,omitempty"`
+	IsDone    bool   `json:"isDone"`
+}
+
+type TodoCardDetailUrl struct {
+	AppUrl string `json:"appUrl"`
+	PcUrl  string `json:"pcUrl"`
+}
+
+// TodoCard 待办列表中的待办
+type TodoCard struct {
+	TaskID       string            `json:"taskId"`
+	Subject      string            `json:"subject"`
+	SourceID     string            `json:"sourceId"`
+	CreatorID    string            `json:"creatorId"`
+	DueTime      EpochMillis       `json:"dueTime"`
+	CreatedTime  EpochMillis       `json:"createdTime"`
+	ModifiedTime EpochMillis       `json:"modifiedTime"`
+	IsDone       bool              `json:"isDone"`
+	Priority     FlexInt           `json:"priority"`
+	BizTag       string            `json:"bizTag"`
+	DetailUrl    TodoCardDetailUrl `json:"detailUrl"`
+}
+
+type QueryTodoTasksResponse struct {
+	TodoCards  []TodoCard `json:"todoCards"`
+	NextToken  string     `json:"nextToken"`
+	TotalCount FlexInt    `json:"totalCount"`
 }
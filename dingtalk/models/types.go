@@ -0,0 +1,175 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultLocation 解析不带时区的时间字符串时使用的时区，钉钉接口返回的都是东八区时间
+var DefaultLocation = time.FixedZone("CST", 8*3600)
+
+// 带时区的格式，日历接口会返回省略秒的 2006-01-02T15:04Z
+var zonedTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+}
+
+var localTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseFlexTime 解析钉钉返回的各种时间格式：毫秒（或秒）时间戳数字或字符串、RFC3339、
+// 2006-01-02 15:04:05、2006-01-02 等，null、空字符串和 0 解析为零值
+func parseFlexTime(data []byte) (time.Time, error) {
+	s, err := unquoteFlex(data)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if s == "" || s == "0" {
+		return time.Time{}, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		// 早于 1973 年的毫秒时间戳在钉钉中不会出现，按秒处理
+		if n > -1e11 && n < 1e11 {
+			return time.Unix(n, 0), nil
+		}
+		return time.UnixMilli(n), nil
+	}
+	for _, layout := range zonedTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range localTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, DefaultLocation); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("models: unsupported time format %q", s)
+}
+
+// unquoteFlex 返回 JSON 字符串的内容或数字的字面值，null 返回空字符串
+func unquoteFlex(data []byte) (string, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		return "", nil
+	}
+	if data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(s), nil
+	}
+	return string(data), nil
+}
+
+// Time 兼容钉钉各种时间格式的 time.Time，序列化为 RFC3339，零值序列化为 null
+type Time struct {
+	time.Time
+}
+
+func (t *Time) UnmarshalJSON(data []byte) error {
+	parsed, err := parseFlexTime(data)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(t.Format(time.RFC3339))
+}
+
+// EpochMillis 毫秒时间戳，同样兼容字符串等其他格式，序列化为毫秒数字，零值序列化为 null
+type EpochMillis struct {
+	time.Time
+}
+
+func (t *EpochMillis) UnmarshalJSON(data []byte) error {
+	parsed, err := parseFlexTime(data)
+	if err != nil {
+		return err
+	}
+	t.Time = parsed
+	return nil
+}
+
+func (t EpochMillis) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(strconv.FormatInt(t.UnixMilli(), 10)), nil
+}
+
+// Date 只有日期的时间，如全天日程，按 DefaultLocation 取日期部分，序列化为 2006-01-02
+type Date struct {
+	time.Time
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	parsed, err := parseFlexTime(data)
+	if err != nil {
+		return err
+	}
+	if !parsed.IsZero() {
+		parsed = parsed.In(DefaultLocation)
+		parsed = time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, DefaultLocation)
+	}
+	d.Time = parsed
+	return nil
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.In(DefaultLocation).Format("2006-01-02"))
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.In(DefaultLocation).Format("2006-01-02")
+}
+
+// FlexInt 兼容数字和数字字符串的整数，null 和空字符串解析为 0，序列化为数字
+type FlexInt int64
+
+func (i *FlexInt) UnmarshalJSON(data []byte) error {
+	s, err := unquoteFlex(data)
+	if err != nil {
+		return err
+	}
+	if s == "" {
+		*i = 0
+		return nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		// 部分接口会返回 15.0 这样的浮点数
+		f, ferr := strconv.ParseFloat(s, 64)
+		if ferr != nil {
+			return fmt.Errorf("models: invalid integer %q", s)
+		}
+		n = int64(f)
+	}
+	*i = FlexInt(n)
+	return nil
+}
+
+func (i FlexInt) Int() int {
+	return int(i)
+}
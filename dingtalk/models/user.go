@@ -1,7 +1,5 @@
 package models
 
-import "time"
-
 type ContactUser struct {
 	Nick      string `json:"nick"`
 	AvatarUrl string `json:"avatarUrl"`
@@ -47,7 +45,7 @@ type TopUser struct {
 	JobNumber        string                `json:"job_number"`
 	Email            string                `json:"email"`
 	LeaderInDept     []TopUserLeaderInDept `json:"leader_in_dept"`
-	CreateTime       time.Time             `json:"create_time"`
+	CreateTime       Time                  `json:"create_time"`
 	Mobile           string                `json:"mobile"`
 	Active           bool                  `json:"active"`
 	Telephone        string                `json:"telephone"`
@@ -57,3 +55,14 @@ type TopUser struct {
 	Name             string                `json:"name"`
 	StateCode        string                `json:"state_code"`
 }
+
+// AuthCodeUserInfo 通过免登授权码获取的用户身份
+type AuthCodeUserInfo struct {
+	UserID            string `json:"userid"`
+	UnionID           string `json:"unionid"`
+	Name              string `json:"name"`
+	DeviceID          string `json:"device_id"`
+	Sys               bool   `json:"sys"`
+	SysLevel          int    `json:"sys_level"`
+	AssociatedUnionID string `json:"associated_unionid"`
+}
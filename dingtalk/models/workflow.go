@@ -0,0 +1,251 @@
+package models
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// ProcessStatus 审批实例状态
+type ProcessStatus string
+
+const (
+	ProcessStatusNew        ProcessStatus = "NEW"
+	ProcessStatusRunning    ProcessStatus = "RUNNING"
+	ProcessStatusTerminated ProcessStatus = "TERMINATED"
+	ProcessStatusCompleted  ProcessStatus = "COMPLETED"
+	ProcessStatusCanceled   ProcessStatus = "CANCELED"
+)
+
+// IsFinished 审批流程是否已结束
+func (s ProcessStatus) IsFinished() bool {
+	return s == ProcessStatusTerminated || s == ProcessStatusCompleted || s == ProcessStatusCanceled
+}
+
+// ProcessResult 审批结果，仅在 ProcessStatusCompleted 时有值
+type ProcessResult string
+
+const (
+	ProcessResultNone   ProcessResult = ""
+	ProcessResultAgree  ProcessResult = "agree"
+	ProcessResultRefuse ProcessResult = "refuse"
+)
+
+// ProcessTaskStatus 审批任务状态
+type ProcessTaskStatus string
+
+const (
+	ProcessTaskStatusNew        ProcessTaskStatus = "NEW"
+	ProcessTaskStatusRunning    ProcessTaskStatus = "RUNNING"
+	ProcessTaskStatusPaused     ProcessTaskStatus = "PAUSED"
+	ProcessTaskStatusCanceled   ProcessTaskStatus = "CANCELED"
+	ProcessTaskStatusCompleted  ProcessTaskStatus = "COMPLETED"
+	ProcessTaskStatusTerminated ProcessTaskStatus = "TERMINATED"
+)
+
+type FormComponentProps struct {
+	ComponentID string          `json:"componentId"`
+	Label       string          `json:"label"`
+	Required    bool            `json:"required"`
+	Placeholder string          `json:"placeholder"`
+	BizAlias    string          `json:"bizAlias"`
+	Options     json.RawMessage `json:"options"`
+	Format      string          `json:"format"`
+	Unit        string          `json:"unit"`
+}
+
+type FormComponent struct {
+	ComponentName string             `json:"componentName"`
+	Props         FormComponentProps `json:"props"`
+	Children      []FormComponent    `json:"children"`
+}
+
+type ProcessSchemaContent struct {
+	Title string          `json:"title"`
+	Icon  string          `json:"icon"`
+	Items []FormComponent `json:"items"`
+}
+
+type ProcessSchema struct {
+	Name          string               `json:"name"`
+	ProcessCode   string               `json:"processCode"`
+	Memo          string               `json:"memo"`
+	IconURL       string               `json:"iconUrl"`
+	SchemaContent ProcessSchemaContent `json:"schemaContent"`
+}
+
+type ProcessSchemaResponse struct {
+	Result ProcessSchema `json:"result"`
+}
+
+// FormComponentValue 表单控件的值，复杂控件的 Value 为 JSON 字符串，可以使用 XxxFormValue 构造
+type FormComponentValue struct {
+	ID            string `json:"id,omitempty"`
+	Name          string `json:"name"`
+	Value         string `json:"value"`
+	ExtValue      string `json:"extValue,omitempty"`
+	ComponentType string `json:"componentType,omitempty"`
+	BizAlias      string `json:"bizAlias,omitempty"`
+}
+
+func TextFormValue(name, value string) FormComponentValue {
+	return FormComponentValue{Name: name, Value: value, ComponentType: "TextField"}
+}
+
+func TextareaFormValue(name, value string) FormComponentValue {
+	return FormComponentValue{Name: name, Value: value, ComponentType: "TextareaField"}
+}
+
+func NumberFormValue(name string, value float64) FormComponentValue {
+	return FormComponentValue{Name: name, Value: strconv.FormatFloat(value, 'f', -1, 64), ComponentType: "NumberField"}
+}
+
+func MoneyFormValue(name string, value float64) FormComponentValue {
+	return FormComponentValue{Name: name, Value: strconv.FormatFloat(value, 'f', 2, 64), ComponentType: "MoneyField"}
+}
+
+// DateFormValue 日期控件，withTime 为 true 时精确到分钟
+func DateFormValue(name string, value time.Time, withTime bool) FormComponentValue {
+	layout := "2006-01-02"
+	if withTime {
+		layout = "2006-01-02 15:04"
+	}
+	return FormComponentValue{Name: name, Value: value.Format(layout), ComponentType: "DDDateField"}
+}
+
+// DateRangeFormValue 日期区间控件，value 为 ["开始","结束"]
+func DateRangeFormValue(name string, start, end time.Time, withTime bool) FormComponentValue {
+	layout := "2006-01-02"
+	if withTime {
+		layout = "2006-01-02 15:04"
+	}
+	value, _ := json.Marshal([]string{start.Format(layout), end.Format(layout)})
+	return FormComponentValue{Name: name, Value: string(value), ComponentType: "DDDateRangeField"}
+}
+
+func SelectFormValue(name, option string) FormComponentValue {
+	return FormComponentValue{Name: name, Value: option, ComponentType: "DDSelectField"}
+}
+
+func MultiSelectFormValue(name string, options []string) FormComponentValue {
+	value, _ := json.Marshal(options)
+	return FormComponentValue{Name: name, Value: string(value), ComponentType: "DDMultiSelectField"}
+}
+
+// TableFormValue 明细控件，每一行为一组控件值
+func TableFormValue(name string, rows [][]FormComponentValue) FormComponentValue {
+	value, _ := json.Marshal(rows)
+	return FormComponentValue{Name: name, Value: string(value), ComponentType: "TableField"}
+}
+
+type ProcessApprover struct {
+	ActionType string   `json:"actionType"`
+	UserIds    []string `json:"userIds"`
+}
+
+type CreateProcessInstanceRequest struct {
+	ProcessCode         string               `json:"processCode"`
+	OriginatorUserID    string               `json:"originatorUserId"`
+	DeptID              int64                `json:"deptId,omitempty"`
+	MicroappAgentID     int64                `json:"microappAgentId,omitempty"`
+	Approvers           []ProcessApprover    `json:"approvers,omitempty"`
+	CcList              []string             `json:"ccList,omitempty"`
+	CcPosition          string               `json:"ccPosition,omitempty"`
+	FormComponentValues []FormComponentValue `json:"formComponentValues"`
+}
+
+type CreateProcessInstanceResponse struct {
+	InstanceID string `json:"instanceId"`
+}
+
+type ProcessOperationRecord struct {
+	UserID string `json:"userId"`
+	Date   Time   `json:"date"`
+	Type   string `json:"type"`
+	Result string `json:"result"`
+	Remark string `json:"remark"`
+}
+
+type ProcessTask struct {
+	TaskID     int64             `json:"taskId"`
+	UserID     string            `json:"userId"`
+	Status     ProcessTaskStatus `json:"status"`
+	Result     string            `json:"result"`
+	CreateTime Time              `json:"createTime"`
+	FinishTime Time              `json:"finishTime"`
+	MobileURL  string            `json:"mobileUrl"`
+	PcURL      string            `json:"pcUrl"`
+	ActivityID string            `json:"activityId"`
+}
+
+type ProcessInstance struct {
+	Title                      string                   `json:"title"`
+	CreateTime                 Time                     `json:"createTime"`
+	FinishTime                 Time                     `json:"finishTime"`
+	OriginatorUserID           string                   `json:"originatorUserId"`
+	OriginatorDeptID           string                   `json:"originatorDeptId"`
+	OriginatorDeptName         string                   `json:"originatorDeptName"`
+	Status                     ProcessStatus            `json:"status"`
+	Result                     ProcessResult            `json:"result"`
+	BusinessID                 string                   `json:"businessId"`
+	ApproverUserIds            []string                 `json:"approverUserIds"`
+	CcUserIds                  []string                 `json:"ccUserIds"`
+	BizAction                  string                   `json:"bizAction"`
+	BizData                    string                   `json:"bizData"`
+	MainProcessInstanceID      string                   `json:"mainProcessInstanceId"`
+	AttachedProcessInstanceIds []string                 `json:"attachedProcessInstanceIds"`
+	FormComponentValues        []FormComponentValue     `json:"formComponentValues"`
+	OperationRecords           []ProcessOperationRecord `json:"operationRecords"`
+	Tasks                      []ProcessTask            `json:"tasks"`
+}
+
+type ProcessInstanceResponse struct {
+	Success bool            `json:"success"`
+	Result  ProcessInstance `json:"result"`
+}
+
+type TopListProcessInstanceIDsRequest struct {
+	ProcessCode string `json:"process_code"`
+	StartTime   int64  `json:"start_time"`
+	EndTime     int64  `json:"end_time,omitempty"`
+	Size        int    `json:"size"`
+	Cursor      int64  `json:"cursor"`
+	UserIDList  string `json:"userid_list,omitempty"`
+}
+
+type TopListProcessInstanceIDsResponse struct {
+	List       []string `json:"list"`
+	NextCursor int64    `json:"next_cursor"`
+}
+
+type ProcessCommentFile struct {
+	Photos      []string          `json:"photos,omitempty"`
+	Attachments []ProcessAttached `json:"attachments,omitempty"`
+}
+
+type ProcessAttached struct {
+	SpaceID  string `json:"spaceId"`
+	FileSize string `json:"fileSize"`
+	FileID   string `json:"fileId"`
+	FileName string `json:"fileName"`
+	FileType string `json:"fileType"`
+}
+
+type AddProcessCommentRequest struct {
+	ProcessInstanceID string              `json:"processInstanceId"`
+	Text              string              `json:"text"`
+	CommentUserID     string              `json:"commentUserId"`
+	File              *ProcessCommentFile `json:"file,omitempty"`
+}
+
+type TerminateProcessInstanceRequest struct {
+	ProcessInstanceID string `json:"processInstanceId"`
+	IsSystem          bool   `json:"isSystem"`
+	Remark            string `json:"remark,omitempty"`
+	OperatingUserID   string `json:"operatingUserId,omitempty"`
+}
+
+type ProcessBoolResponse struct {
+	Success bool `json:"success"`
+	Result  bool `json:"result"`
+}
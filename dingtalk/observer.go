@@ -0,0 +1,89 @@
+package dingtalk
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/chzealot/gobase/logger"
+)
+
+// CallInfo 一次 Call 的结果，包括所有重试
+type CallInfo struct {
+	// Endpoint 为替换占位符前的接口路径，如 /v1.0/contact/users/{unionId}
+	Endpoint string
+	Family   Family
+	Method   string
+	// StatusCode 最后一次请求的 HTTP 状态码，未收到响应时为 0
+	StatusCode int
+	// Code 出错时为 errcode 或 code
+	Code string
+	Err  error
+	// Latency 包括重试和退避等待的总耗时
+	Latency time.Duration
+	// Attempts 发出的请求次数，重试次数为 Attempts-1，token 失效后的刷新重试也计入
+	Attempts int
+}
+
+// Retries 返回重试次数
+func (i CallInfo) Retries() int {
+	if i.Attempts <= 1 {
+		return 0
+	}
+	return i.Attempts - 1
+}
+
+// Observer 观察 Client 发出的每次调用，用于指标和链路追踪，需要并发安全
+type Observer interface {
+	// CallStarted 在调用开始时执行，返回的 context 用于本次调用的请求和 CallFinished
+	CallStarted(ctx context.Context, ep Endpoint) context.Context
+	CallFinished(ctx context.Context, info CallInfo)
+	// TokenCache 在 GetAccessToken 时执行，hit 表示使用了缓存的 token
+	TokenCache(hit bool)
+}
+
+type parentSpanKey struct{}
+
+// TraceObserver 为每次调用创建子 span，调用结束后通过 logger 输出，
+// 日志中包含 trace_id、span_id 和 parent_span_id，context 中没有 trace_id 时创建新的 trace
+type TraceObserver struct{}
+
+func (TraceObserver) CallStarted(ctx context.Context, ep Endpoint) context.Context {
+	traceID := logger.GetTraceID(ctx)
+	if traceID == "" {
+		traceID = randomHex(16)
+	}
+	ctx = context.WithValue(ctx, parentSpanKey{}, logger.GetSpanID(ctx))
+	return logger.WithTrace(ctx, traceID, randomHex(8))
+}
+
+func (TraceObserver) CallFinished(ctx context.Context, info CallInfo) {
+	parentSpanID, _ := ctx.Value(parentSpanKey{}).(string)
+	kvs := []interface{}{
+		"parent_span_id", parentSpanID,
+		"endpoint", info.Endpoint,
+		"method", info.Method,
+		"status", info.StatusCode,
+		"code", info.Code,
+		"latency", info.Latency,
+		"retries", info.Retries(),
+	}
+	if info.Err != nil {
+		logger.WarnwCtx(ctx, "dingtalk span", append(kvs, "error", info.Err)...)
+		return
+	}
+	logger.InfowCtx(ctx, "dingtalk span", kvs...)
+}
+
+func (TraceObserver) TokenCache(hit bool) {
+	if !hit {
+		logger.Debugw("dingtalk.GetAccessToken, token cache miss")
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,108 @@
+package dingtalk
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/chzealot/gobase/logger"
+)
+
+type recordObserver struct {
+	mutex  sync.Mutex
+	spans  []string
+	calls  []CallInfo
+	tokens []bool
+}
+
+func (o *recordObserver) CallStarted(ctx context.Context, ep Endpoint) context.Context {
+	return ctx
+}
+
+func (o *recordObserver) CallFinished(ctx context.Context, info CallInfo) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.spans = append(o.spans, logger.GetSpanID(ctx))
+	o.calls = append(o.calls, info)
+}
+
+func (o *recordObserver) TokenCache(hit bool) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.tokens = append(o.tokens, hit)
+}
+
+func TestObservers(t *testing.T) {
+	var throttled bool
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/topapi/echo":
+			if !throttled {
+				throttled = true
+				_, _ = w.Write([]byte(`{"errcode":90018,"errmsg":"too frequent"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"errcode":0,"result":{"name":"ok"}}`))
+		case "/v1.0/users/u1":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"UserNotExist","message":"not found"}`))
+		}
+	})
+	record := &recordObserver{}
+	metrics := NewPrometheusObserver()
+	WithObserver(TraceObserver{})(client)
+	WithObserver(record)(client)
+	WithObserver(metrics)(client)
+
+	ctx := logger.WithTrace(context.Background(), "trace-1", "span-parent")
+	if err := client.Call(ctx, OAPI("/topapi/echo"), echoRequest{}, nil); err != nil {
+		t.Fatal(err)
+	}
+	ep := API(http.MethodGet, "/v1.0/users/{unionId}")
+	ep.PathParams = []string{"u1"}
+	if err := client.Call(ctx, ep, nil, nil); !IsNotFound(err) {
+		t.Fatalf("err=%v", err)
+	}
+
+	// gettoken、/topapi/echo、/v1.0/users/{unionId}
+	if len(record.calls) != 3 {
+		t.Fatalf("calls = %+v", record.calls)
+	}
+	echo, user := record.calls[1], record.calls[2]
+	if echo.Endpoint != "/topapi/echo" || echo.Attempts != 2 || echo.StatusCode != 200 || echo.Err != nil {
+		t.Errorf("echo = %+v", echo)
+	}
+	if user.Endpoint != "/v1.0/users/{unionId}" || user.StatusCode != 404 || user.Code != "UserNotExist" {
+		t.Errorf("user = %+v", user)
+	}
+	for _, span := range record.spans {
+		if span == "" || span == "span-parent" {
+			t.Errorf("span = %q, want child span", span)
+		}
+	}
+	if len(record.tokens) != 3 || record.tokens[0] || !record.tokens[2] {
+		t.Errorf("token cache = %v", record.tokens)
+	}
+
+	out := &bytes.Buffer{}
+	if _, err := metrics.WriteTo(out); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`dingtalk_requests_total{endpoint="/topapi/echo",method="POST",status="200",code=""} 1`,
+		`dingtalk_requests_total{endpoint="/v1.0/users/{unionId}",method="GET",status="404",code="UserNotExist"} 1`,
+		`dingtalk_request_retries_total{endpoint="/topapi/echo",method="POST"} 1`,
+		`dingtalk_request_duration_seconds_count{endpoint="/topapi/echo",method="POST"} 1`,
+		`dingtalk_token_cache_total{result="miss"} 1`,
+		`dingtalk_token_cache_total{result="hit"} 2`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("missing %s in\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package dingtalk
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Option 用于 NewDingTalkClient 的可选配置
+type Option func(*Client)
+
+// WithHTTPClient 替换默认的 http.Client，如需要代理或自定义 Transport 时使用
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = client
+	}
+}
+
+// WithTimeout 设置单次请求的超时时间，默认 60 秒
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient = &http.Client{Timeout: timeout, Transport: c.httpClient.Transport}
+	}
+}
+
+// WithRetry 设置限流、服务端错误时的最大重试次数和初始退避时间，maxRetries 为 0 时不重试。
+// 服务端错误和网络错误只重试 GET、HEAD、PUT、DELETE 请求
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+	}
+}
+
+// WithTokenSource 替换默认的应用 access_token 获取方式
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) {
+		c.tokenSource = source
+	}
+}
+
+// WithAuditLog 通过 logger 记录每次请求的审计日志，敏感字段会被隐藏，
+// maxBodySize 为请求、响应内容记录的最大长度，0 使用默认值 2048
+func WithAuditLog(maxBodySize int) Option {
+	return func(c *Client) {
+		c.auditBodySize = maxBodySize
+		c.audit = true
+	}
+}
+
+// WithLimiter 替换默认的客户端限流器（NewDefaultLimiter），limiter 为 nil 时不限流。
+// 使用 NewLimiter 创建的限流器不包含 DefaultEndpointLimits，需要时通过 Limiter.SetLimit 设置
+func WithLimiter(limiter *Limiter) Option {
+	return func(c *Client) {
+		c.limiter = limiter
+	}
+}
+
+// WithBaseURL 替换 oapi.dingtalk.com 和 api.dingtalk.com 的地址，如使用 dingtalktest 测试服务器或代理，
+// 参数为空时使用默认地址
+func WithBaseURL(oapiBaseURL, apiBaseURL string) Option {
+	return func(c *Client) {
+		c.oapiBaseURL = strings.TrimSuffix(oapiBaseURL, "/")
+		c.apiBaseURL = strings.TrimSuffix(apiBaseURL, "/")
+	}
+}
+
+// WithObserver 添加调用观察者，用于记录指标和链路追踪，可以多次使用
+func WithObserver(o Observer) Option {
+	return func(c *Client) {
+		c.observers = append(c.observers, o)
+	}
+}
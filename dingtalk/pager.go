@@ -0,0 +1,249 @@
+package dingtalk
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Page 分页接口返回的一页数据
+type Page[T any] struct {
+	Items []T
+	// Next 下一页的游标，HasMore 为 false 或 Next 为空时表示没有更多数据
+	Next    string
+	HasMore bool
+}
+
+// PageFunc 根据游标获取一页数据，第一页的 cursor 为空
+type PageFunc[T any] func(ctx context.Context, cursor string) (*Page[T], error)
+
+type pageResult[T any] struct {
+	page *Page[T]
+	err  error
+}
+
+// Pager 统一 nextToken、cursor/has_more、offset/size 等分页方式的迭代器。
+// 返回一页数据后在后台预取下一页，提前结束遍历时调用 Close 取消预取
+//
+//	pager := client.IterateAttendanceGroups()
+//	defer pager.Close()
+//	for pager.Next(ctx) {
+//		group := pager.Item()
+//	}
+//	if err := pager.Err(); err != nil {
+//	}
+//
+// 按页遍历时使用 NextPage，同一个 Pager 不要混用 Next 和 NextPage
+type Pager[T any] struct {
+	fetch   PageFunc[T]
+	cursor  string
+	done    bool
+	pending chan pageResult[T]
+	cancel  context.CancelFunc
+	// parent 发起预取的 NextPage 的 ctx
+	parent  context.Context
+	buffer  []T
+	current T
+	err     error
+}
+
+func NewPager[T any](fetch PageFunc[T]) *Pager[T] {
+	return &Pager[T]{fetch: fetch}
+}
+
+// Next 移动到下一条数据，没有更多数据、出错或 ctx 取消时返回 false
+func (p *Pager[T]) Next(ctx context.Context) bool {
+	for len(p.buffer) == 0 {
+		items, ok := p.NextPage(ctx)
+		if !ok {
+			return false
+		}
+		p.buffer = items
+	}
+	p.current = p.buffer[0]
+	p.buffer = p.buffer[1:]
+	return true
+}
+
+func (p *Pager[T]) Item() T {
+	return p.current
+}
+
+// NextPage 返回下一页数据，没有更多数据、出错或 ctx 取消时返回 false
+func (p *Pager[T]) NextPage(ctx context.Context) ([]T, bool) {
+	if p.err != nil || (p.done && p.pending == nil) {
+		return nil, false
+	}
+	var result pageResult[T]
+	if p.pending != nil {
+		select {
+		case result = <-p.pending:
+		case <-ctx.Done():
+			p.err = ctx.Err()
+			p.Close()
+			return nil, false
+		}
+		p.pending = nil
+		p.cancel()
+		// 预取使用的是上一次调用的 ctx，它已经结束而本次的 ctx 仍然有效时使用本次的 ctx 重新获取
+		if result.err != nil && p.parent.Err() != nil && ctx.Err() == nil {
+			result.page, result.err = p.fetch(ctx, p.cursor)
+		}
+		p.parent = nil
+	} else {
+		if err := ctx.Err(); err != nil {
+			p.err = err
+			return nil, false
+		}
+		result.page, result.err = p.fetch(ctx, p.cursor)
+	}
+	if result.err != nil {
+		p.err = result.err
+		return nil, false
+	}
+	page := result.page
+	if page == nil || !page.HasMore || page.Next == "" || page.Next == p.cursor {
+		p.done = true
+	} else {
+		p.cursor = page.Next
+		p.prefetch(ctx)
+	}
+	if page == nil {
+		return nil, true
+	}
+	return page.Items, true
+}
+
+func (p *Pager[T]) prefetch(parent context.Context) {
+	ctx, cancel := context.WithCancel(parent)
+	pending := make(chan pageResult[T], 1)
+	cursor := p.cursor
+	p.cancel = cancel
+	p.pending = pending
+	p.parent = parent
+	go func() {
+		page, err := p.fetch(ctx, cursor)
+		pending <- pageResult[T]{page: page, err: err}
+	}()
+}
+
+func (p *Pager[T]) Err() error {
+	return p.err
+}
+
+// Close 取消正在进行的预取并结束遍历
+func (p *Pager[T]) Close() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.pending = nil
+	p.done = true
+	p.buffer = nil
+}
+
+// All 获取所有数据，数据量较大时请使用 Next 逐条遍历
+func (p *Pager[T]) All(ctx context.Context) ([]T, error) {
+	defer p.Close()
+	var items []T
+	for {
+		page, ok := p.NextPage(ctx)
+		if !ok {
+			return items, p.Err()
+		}
+		items = append(items, page...)
+	}
+}
+
+// TokenPages nextToken 分页，如日历、待办等 api.dingtalk.com 接口，返回的 nextToken 为空表示没有更多数据
+func TokenPages[T any](fetch func(ctx context.Context, token string) (items []T, nextToken string, err error)) PageFunc[T] {
+	return func(ctx context.Context, cursor string) (*Page[T], error) {
+		items, next, err := fetch(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		return &Page[T]{Items: items, Next: next, HasMore: next != ""}, nil
+	}
+}
+
+// CursorPages cursor/has_more 分页，如通讯录、审批等 topapi 接口，第一页的 cursor 为 0
+func CursorPages[T any](fetch func(ctx context.Context, cursor int64) (items []T, nextCursor int64, hasMore bool, err error)) PageFunc[T] {
+	return func(ctx context.Context, cursor string) (*Page[T], error) {
+		var current int64
+		if cursor != "" {
+			var err error
+			if current, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+				return nil, err
+			}
+		}
+		items, next, hasMore, err := fetch(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		return &Page[T]{Items: items, Next: strconv.FormatInt(next, 10), HasMore: hasMore}, nil
+	}
+}
+
+// OffsetPages offset/size 分页，如考勤等 topapi 接口
+func OffsetPages[T any](size int, fetch func(ctx context.Context, offset, size int) (items []T, hasMore bool, err error)) PageFunc[T] {
+	return func(ctx context.Context, cursor string) (*Page[T], error) {
+		var offset int
+		if cursor != "" {
+			var err error
+			if offset, err = strconv.Atoi(cursor); err != nil {
+				return nil, err
+			}
+		}
+		items, hasMore, err := fetch(ctx, offset, size)
+		if err != nil {
+			return nil, err
+		}
+		return &Page[T]{Items: items, Next: strconv.Itoa(offset + size), HasMore: hasMore}, nil
+	}
+}
+
+// batchedPages 接口限制了单次查询的用户数时，对每一批用户依次使用 pages 分页，
+// 游标格式为 "批次序号:批次内游标"
+func batchedPages[T any](userIds []string, batchSize int, pages func(batch []string) PageFunc[T]) PageFunc[T] {
+	batches := chunk(userIds, batchSize)
+	return func(ctx context.Context, cursor string) (*Page[T], error) {
+		index, inner := 0, ""
+		if cursor != "" {
+			before, after, ok := strings.Cut(cursor, ":")
+			if !ok {
+				return nil, fmt.Errorf("dingtalk.Pager, invalid cursor %q", cursor)
+			}
+			var err error
+			if index, err = strconv.Atoi(before); err != nil {
+				return nil, err
+			}
+			inner = after
+		}
+		if index >= len(batches) {
+			return &Page[T]{}, nil
+		}
+		page, err := pages(batches[index])(ctx, inner)
+		if err != nil {
+			return nil, err
+		}
+		if page.HasMore && page.Next != "" {
+			page.Next = strconv.Itoa(index) + ":" + page.Next
+		} else {
+			page.Next = strconv.Itoa(index+1) + ":"
+			page.HasMore = index+1 < len(batches)
+		}
+		return page, nil
+	}
+}
+
+func chunk(items []string, size int) [][]string {
+	var chunks [][]string
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		chunks = append(chunks, items[start:end])
+	}
+	return chunks
+}
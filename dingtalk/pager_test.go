@@ -0,0 +1,113 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPagerStyles(t *testing.T) {
+	ctx := context.Background()
+
+	tokens := map[string][]string{"": {"a", "b"}, "t1": {}, "t2": {"c"}}
+	nextTokens := map[string]string{"": "t1", "t1": "t2", "t2": ""}
+	items, err := NewPager(TokenPages(func(ctx context.Context, token string) ([]string, string, error) {
+		return tokens[token], nextTokens[token], nil
+	})).All(ctx)
+	if err != nil || !reflect.DeepEqual(items, []string{"a", "b", "c"}) {
+		t.Errorf("token pages: %v, %v", items, err)
+	}
+
+	ids, err := NewPager(CursorPages(func(ctx context.Context, cursor int64) ([]int64, int64, bool, error) {
+		return []int64{cursor}, cursor + 10, cursor < 20, nil
+	})).All(ctx)
+	if err != nil || !reflect.DeepEqual(ids, []int64{0, 10, 20}) {
+		t.Errorf("cursor pages: %v, %v", ids, err)
+	}
+
+	users := []string{"u1", "u2", "u3", "u4", "u5"}
+	var offsets []string
+	got, err := NewPager(batchedPages(users, 2, func(batch []string) PageFunc[string] {
+		return OffsetPages(1, func(ctx context.Context, offset, size int) ([]string, bool, error) {
+			offsets = append(offsets, strconv.Itoa(offset))
+			return batch[offset : offset+size], offset+size < len(batch), nil
+		})
+	})).All(ctx)
+	if err != nil || !reflect.DeepEqual(got, users) {
+		t.Errorf("batched offset pages: %v, %v", got, err)
+	}
+	if !reflect.DeepEqual(offsets, []string{"0", "1", "0", "1", "0"}) {
+		t.Errorf("offsets = %v", offsets)
+	}
+}
+
+func TestPagerStopsOnErrorAndCancel(t *testing.T) {
+	boom := errors.New("boom")
+	pager := NewPager(OffsetPages(2, func(ctx context.Context, offset, size int) ([]int, bool, error) {
+		if offset >= 4 {
+			return nil, false, boom
+		}
+		return []int{offset, offset + 1}, true, nil
+	}))
+	var seen []int
+	for pager.Next(context.Background()) {
+		seen = append(seen, pager.Item())
+	}
+	if !errors.Is(pager.Err(), boom) || !reflect.DeepEqual(seen, []int{0, 1, 2, 3}) {
+		t.Errorf("seen=%v, err=%v", seen, pager.Err())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pager = NewPager(OffsetPages(1, func(ctx context.Context, offset, size int) ([]int, bool, error) {
+		if offset > 0 {
+			<-ctx.Done()
+			return nil, false, ctx.Err()
+		}
+		return []int{offset}, true, nil
+	}))
+	if !pager.Next(ctx) {
+		t.Fatalf("first item: %v", pager.Err())
+	}
+	cancel()
+	if pager.Next(ctx) || !errors.Is(pager.Err(), context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", pager.Err())
+	}
+	pager.Close()
+}
+
+func TestPagerPrefetchOutlivesRequestContext(t *testing.T) {
+	var calls int32
+	pager := NewPager(OffsetPages(1, func(ctx context.Context, offset, size int) ([]int, bool, error) {
+		atomic.AddInt32(&calls, 1)
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		case <-time.After(time.Millisecond * 20):
+			return []int{offset}, offset < 1, nil
+		}
+	}))
+	defer pager.Close()
+
+	// 每一页使用独立的请求 ctx，上一次的 ctx 结束不影响下一页
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	page, ok := pager.NextPage(ctx1)
+	cancel1()
+	if !ok || !reflect.DeepEqual(page, []int{0}) {
+		t.Fatalf("first page = %v, err = %v", page, pager.Err())
+	}
+	page, ok = pager.NextPage(context.Background())
+	if !ok || !reflect.DeepEqual(page, []int{1}) {
+		t.Fatalf("second page = %v, err = %v", page, pager.Err())
+	}
+	if _, ok = pager.NextPage(context.Background()); ok || pager.Err() != nil {
+		t.Errorf("third page ok = %v, err = %v", ok, pager.Err())
+	}
+	// 第一页、被取消的预取和重新获取的第二页
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Errorf("fetch calls = %d, want 3", n)
+	}
+}
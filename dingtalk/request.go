@@ -0,0 +1,448 @@
+package dingtalk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	url2 "net/url"
+	"strings"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/chzealot/gobase/logger"
+)
+
+const (
+	DefaultOAPIBaseURL = "https://oapi.dingtalk.com"
+	DefaultAPIBaseURL  = "https://api.dingtalk.com"
+
+	defaultMaxRetries   = 2
+	defaultRetryBackoff = time.Millisecond * 200
+)
+
+// Family 钉钉开放接口的两类域名，鉴权方式和响应格式不同
+type Family int
+
+const (
+	// FamilyOAPI oapi.dingtalk.com 接口，access_token 通过 query 参数传递，
+	// 响应为 errcode、errmsg、result 格式
+	FamilyOAPI Family = iota
+	// FamilyAPI api.dingtalk.com 接口，access_token 通过 x-acs-dingtalk-access-token header 传递，
+	// 出错时返回非 2xx 状态码和 code、message
+	FamilyAPI
+)
+
+// Endpoint 描述一个钉钉接口
+type Endpoint struct {
+	Family Family
+	// Method 为空时使用 POST
+	Method string
+	// Path 可以包含 {unionId} 这样的占位符，按顺序替换为 PathParams，日志中使用替换前的 Path
+	Path       string
+	PathParams []string
+	Query      url2.Values
+	// RawURL 不为空时请求该地址而不是域名拼接 Path，如机器人消息文件的下载地址，
+	// 此时 Path 仅用于日志、限流和指标
+	RawURL string
+	// AccessToken 不为空时使用该 token（如用户 token）代替应用 access_token
+	AccessToken string
+	// NoAuth 不传递 access_token，如 gettoken 接口
+	NoAuth bool
+}
+
+// OAPI 返回 oapi.dingtalk.com 的 POST 接口
+func OAPI(path string) Endpoint {
+	return Endpoint{Family: FamilyOAPI, Method: http.MethodPost, Path: path}
+}
+
+// API 返回 api.dingtalk.com 的接口
+func API(method, path string) Endpoint {
+	return Endpoint{Family: FamilyAPI, Method: method, Path: path}
+}
+
+// Do 使用自定义的请求和响应结构调用任意钉钉接口，处理鉴权、错误码、重试和日志。
+// oapi 接口的响应中有 result 字段时解码 result，否则解码整个响应；
+// GET 请求或 req 为 nil 时不发送请求体
+//
+//	resp, err := dingtalk.Do[MyRequest, MyResponse](ctx, client, dingtalk.OAPI("/topapi/xxx"), req)
+func Do[Req, Resp any](ctx context.Context, c *Client, ep Endpoint, req Req) (*Resp, error) {
+	resp := new(Resp)
+	if err := c.Call(ctx, ep, req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Call 与 Do 相同，resp 为 nil 时忽略响应内容
+func (c *Client) Call(ctx context.Context, ep Endpoint, req, resp interface{}) error {
+	method := ep.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	for _, o := range c.observers {
+		ctx = o.CallStarted(ctx, ep)
+	}
+	info := &CallInfo{Endpoint: ep.Path, Family: ep.Family, Method: method}
+	start := time.Now()
+	err := c.call(ctx, ep, method, req, resp, info)
+	info.Latency = time.Since(start)
+	info.Err = err
+	apiErr := &Error{}
+	if errors.As(err, &apiErr) {
+		info.StatusCode = apiErr.StatusCode
+		info.Code = apiErr.Code
+	}
+	for _, o := range c.observers {
+		o.CallFinished(ctx, *info)
+	}
+	return err
+}
+
+// call 执行请求，包括限流、token 刷新和重试，请求次数和状态码记录在 info 中
+func (c *Client) call(ctx context.Context, ep Endpoint, method string, req, resp interface{}, info *CallInfo) error {
+	body, err := newRequestBody(method, req)
+	if err != nil {
+		return err
+	}
+	backoff := c.retryBackoff
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		if c.limiter != nil {
+			if err := c.limiter.Wait(ctx, ep.Path); err != nil {
+				logger.WarnwCtx(ctx, "dingtalk.Call, rate limited", "method", method, "path", ep.Path, "error", err)
+				return err
+			}
+		}
+		info.Attempts++
+		statusCode, err := c.callOnce(ctx, ep, method, body, resp)
+		info.StatusCode = statusCode
+		if err == nil {
+			return nil
+		}
+		replayable := body == nil || body.replayable
+		// token 失效时清除缓存并重试一次，不计入重试次数
+		if !refreshed && replayable && ep.AccessToken == "" && !ep.NoAuth && isTokenInvalid(err) {
+			refreshed = true
+			c.resetAccessToken()
+			attempt--
+			continue
+		}
+		if attempt >= c.maxRetries || !replayable || !isRetryable(ctx, method, err) {
+			logger.WarnwCtx(ctx, "dingtalk.Call, request failed",
+				"method", method, "path", ep.Path, "attempt", attempt+1, "error", err)
+			return err
+		}
+		logger.InfowCtx(ctx, "dingtalk.Call, retrying",
+			"method", method, "path", ep.Path, "attempt", attempt+1, "backoff", backoff, "error", err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) callOnce(ctx context.Context, ep Endpoint, method string, body *requestBody, resp interface{}) (int, error) {
+	query := url2.Values{}
+	for k, v := range ep.Query {
+		query[k] = v
+	}
+	token := ep.AccessToken
+	if token == "" && !ep.NoAuth {
+		var err error
+		if token, err = c.GetAccessToken(); err != nil {
+			return 0, err
+		}
+	}
+	url, err := c.endpointURL(ep)
+	if err != nil {
+		return 0, err
+	}
+	if ep.Family == FamilyOAPI && token != "" {
+		query.Set("access_token", token)
+	}
+	if len(query) > 0 {
+		sep := "?"
+		if strings.Contains(url, "?") {
+			sep = "&"
+		}
+		url += sep + query.Encode()
+	}
+	var reader io.Reader
+	wait := func() error { return nil }
+	if body != nil {
+		if reader, wait, err = body.open(); err != nil {
+			return 0, err
+		}
+	}
+	r, err := http.NewRequestWithContext(withEndpoint(ctx, ep.Path), method, url, reader)
+	if err != nil {
+		_ = wait()
+		return 0, err
+	}
+	if body != nil {
+		r.Header.Add("Content-Type", body.contentType)
+	}
+	if ep.Family == FamilyAPI && token != "" {
+		r.Header.Add("x-acs-dingtalk-access-token", token)
+	}
+	stream, isStream := resp.(*streamResponse)
+	httpClient := c.getHTTPClient()
+	if isStream {
+		// 下载大文件时不设置整体超时，由 ctx 控制
+		httpClient = &http.Client{Transport: httpClient.Transport, CheckRedirect: httpClient.CheckRedirect, Jar: httpClient.Jar}
+	}
+	res, err := httpClient.Do(r)
+	// 生成请求体出错（如文件超过大小限制）时返回该错误，而不是请求被中断的错误
+	if bodyErr := wait(); bodyErr != nil {
+		if err == nil {
+			res.Body.Close()
+		}
+		return 0, bodyErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if isStream {
+		return res.StatusCode, stream.receive(ep, res)
+	}
+	defer res.Body.Close()
+	respBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return res.StatusCode, err
+	}
+	if ep.Family == FamilyOAPI {
+		return res.StatusCode, decodeTopResult(res.StatusCode, respBytes, resp)
+	}
+	return res.StatusCode, decodeApiResult(res.StatusCode, respBytes, resp)
+}
+
+// endpointURL 返回不含 query 参数的请求地址
+func (c *Client) endpointURL(ep Endpoint) (string, error) {
+	if ep.RawURL != "" {
+		return ep.RawURL, nil
+	}
+	path, err := ep.expandPath()
+	if err != nil {
+		return "", err
+	}
+	return c.baseURL(ep.Family) + path, nil
+}
+
+// requestBody 请求体，每次尝试调用 open 生成新的 io.Reader
+type requestBody struct {
+	contentType string
+	// open 返回本次尝试的请求体，wait 在请求结束后调用，返回生成请求体时的错误
+	open func() (body io.Reader, wait func() error, err error)
+	// replayable 为 false 时请求体只能发送一次，出错时不刷新 token 也不重试
+	replayable bool
+}
+
+// newRequestBody 将 req 编码为 JSON 请求体，GET 请求或 req 为 nil 时返回 nil
+func newRequestBody(method string, req interface{}) (*requestBody, error) {
+	if body, ok := req.(*requestBody); ok {
+		return body, nil
+	}
+	if req == nil || method == http.MethodGet || method == http.MethodHead {
+		return nil, nil
+	}
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	// Do 的 Req 为指针类型且传入 nil 时同样不发送请求体
+	if string(reqBytes) == "null" {
+		return nil, nil
+	}
+	return &requestBody{
+		contentType: "application/json",
+		replayable:  true,
+		open: func() (io.Reader, func() error, error) {
+			return bytes.NewReader(reqBytes), func() error { return nil }, nil
+		},
+	}, nil
+}
+
+// streamResponse 作为 Call 的 resp 时不读取响应内容，成功时由调用方读取并关闭 body
+type streamResponse struct {
+	body io.ReadCloser
+}
+
+// streamErrorPeekSize 200 的 JSON 响应中最多读取这么多字节判断是否为错误信息，错误信息不会超过该大小
+const streamErrorPeekSize = 1 << 12
+
+func (s *streamResponse) receive(ep Endpoint, res *http.Response) error {
+	if res.StatusCode == http.StatusOK && !strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+		s.body = res.Body
+		return nil
+	}
+	if res.StatusCode == http.StatusOK {
+		// 下载的文件本身也可能是 JSON，只读取开头判断是否为错误信息，其余内容继续流式读取
+		peeked := make([]byte, streamErrorPeekSize)
+		n, err := io.ReadFull(res.Body, peeked)
+		peeked = peeked[:n]
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			defer res.Body.Close()
+			envelope := models.TopResult[json.RawMessage]{}
+			if json.Unmarshal(peeked, &envelope) == nil && envelope.ErrorCode != 0 {
+				return newTopError(res.StatusCode, envelope.ErrorCode, envelope.ErrorMessage, envelope.RequestID)
+			}
+			s.body = io.NopCloser(bytes.NewReader(peeked))
+			return nil
+		}
+		if err != nil {
+			res.Body.Close()
+			return err
+		}
+		s.body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(peeked), res.Body), res.Body}
+		return nil
+	}
+	// 出错时返回的是 JSON 格式的错误信息而不是文件内容
+	defer res.Body.Close()
+	respBytes, err := io.ReadAll(io.LimitReader(res.Body, 1<<16))
+	if err != nil {
+		return err
+	}
+	envelope := models.TopResult[json.RawMessage]{}
+	if json.Unmarshal(respBytes, &envelope) == nil && envelope.ErrorCode != 0 {
+		return newTopError(res.StatusCode, envelope.ErrorCode, envelope.ErrorMessage, envelope.RequestID)
+	}
+	if ep.Family == FamilyAPI {
+		return decodeApiResult(res.StatusCode, respBytes, nil)
+	}
+	return &Error{StatusCode: res.StatusCode, Message: string(respBytes)}
+}
+
+// expandPath 将 Path 中的占位符依次替换为转义后的 PathParams
+func (ep Endpoint) expandPath() (string, error) {
+	path := ep.Path
+	for _, param := range ep.PathParams {
+		start := strings.Index(path, "{")
+		end := strings.Index(path, "}")
+		if start < 0 || end < start {
+			return "", fmt.Errorf("dingtalk.Endpoint, too many path params for %s", ep.Path)
+		}
+		path = path[:start] + url2.PathEscape(param) + path[end+1:]
+	}
+	if strings.Contains(path, "{") {
+		return "", fmt.Errorf("dingtalk.Endpoint, missing path params for %s", ep.Path)
+	}
+	return path, nil
+}
+
+type endpointKey struct{}
+
+// withEndpoint 将接口模板写入请求的 context，供 AuditTransport 等记录日志
+func withEndpoint(ctx context.Context, template string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, template)
+}
+
+func endpointFromContext(ctx context.Context) string {
+	template, _ := ctx.Value(endpointKey{}).(string)
+	return template
+}
+
+// baseURL 返回接口域名，可通过 WithBaseURL 替换为测试服务器
+func (c *Client) baseURL(family Family) string {
+	if family == FamilyOAPI {
+		if c.oapiBaseURL != "" {
+			return c.oapiBaseURL
+		}
+		return DefaultOAPIBaseURL
+	}
+	if c.apiBaseURL != "" {
+		return c.apiBaseURL
+	}
+	return DefaultAPIBaseURL
+}
+
+func (c *Client) getHTTPClient() *http.Client {
+	if c.httpClient == nil {
+		return &http.Client{Timeout: defaultTimeout}
+	}
+	return c.httpClient
+}
+
+// doApi 调用 api.dingtalk.com 的接口，应用 token 通过 x-acs-dingtalk-access-token header 传递
+func (c *Client) doApi(ctx context.Context, method, path string, query url2.Values, req, resp interface{}) error {
+	ep := API(method, path)
+	ep.Query = query
+	return c.Call(ctx, ep, req, resp)
+}
+
+// doTopApi 调用 oapi.dingtalk.com 的接口，应用 token 通过 access_token 参数传递。
+// 响应中有 result 字段时解码 result，否则解码整个响应
+func (c *Client) doTopApi(ctx context.Context, path string, req, resp interface{}) error {
+	return c.Call(ctx, OAPI(path), req, resp)
+}
+
+func decodeApiResult(statusCode int, respBytes []byte, resp interface{}) error {
+	if statusCode < 200 || statusCode >= 300 {
+		errResp := &models.OpenApiErrorResponse{}
+		_ = json.Unmarshal(respBytes, errResp)
+		return &Error{
+			StatusCode: statusCode,
+			Code:       errResp.Code,
+			Message:    errResp.Message,
+			RequestID:  errResp.RequestID,
+		}
+	}
+	if resp == nil || len(respBytes) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBytes, resp)
+}
+
+func decodeTopResult(statusCode int, respBytes []byte, resp interface{}) error {
+	envelope := models.TopResult[json.RawMessage]{}
+	if err := json.Unmarshal(respBytes, &envelope); err != nil {
+		return &Error{StatusCode: statusCode, Code: "InvalidResponse", Message: err.Error()}
+	}
+	if statusCode != http.StatusOK || envelope.ErrorCode != 0 {
+		return newTopError(statusCode, envelope.ErrorCode, envelope.ErrorMessage, envelope.RequestID)
+	}
+	if resp == nil {
+		return nil
+	}
+	if len(envelope.Result) > 0 && string(envelope.Result) != "null" {
+		return json.Unmarshal(envelope.Result, resp)
+	}
+	return json.Unmarshal(respBytes, resp)
+}
+
+func isTokenInvalid(err error) bool {
+	return ErrorKindOf(err) == ErrorKindAuth
+}
+
+func isRetryable(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	idempotent := method == http.MethodGet || method == http.MethodHead ||
+		method == http.MethodPut || method == http.MethodDelete
+	apiErr := &Error{}
+	if !errors.As(err, &apiErr) {
+		// 网络错误时请求可能已经被处理，只重试幂等的请求
+		return idempotent
+	}
+	// 限流时请求没有被处理，可以重试；服务端错误时请求可能已经被处理（如网关超时），同样只重试幂等的请求，
+	// 避免重复创建审批、待办或重复发送消息
+	switch apiErr.Kind() {
+	case ErrorKindRateLimited:
+		return true
+	case ErrorKindServer:
+		return idempotent
+	default:
+		return false
+	}
+}
@@ -0,0 +1,186 @@
+package dingtalk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chzealot/gobase/logger"
+)
+
+// rewriteTransport 将请求转发到测试服务器，保留原始 host 便于断言
+type rewriteTransport struct {
+	target *url.URL
+}
+
+func (t *rewriteTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Header.Set("X-Original-Host", r.URL.Host)
+	r.URL.Scheme = t.target.Scheme
+	r.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	_ = logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff})
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	target, _ := url.Parse(server.URL)
+	return NewDingTalkClient("id", "secret",
+		WithHTTPClient(&http.Client{Transport: &rewriteTransport{target: target}}),
+		WithRetry(2, time.Millisecond))
+}
+
+type echoRequest struct {
+	Name string `json:"name"`
+}
+
+type echoResponse struct {
+	Name string `json:"name"`
+}
+
+func TestDoFamilies(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/topapi/echo":
+			if r.Header.Get("X-Original-Host") != "oapi.dingtalk.com" || r.URL.Query().Get("access_token") != "app-token" {
+				t.Errorf("unexpected oapi request: %s %s", r.Header.Get("X-Original-Host"), r.URL)
+			}
+			req := &echoRequest{}
+			_ = json.NewDecoder(r.Body).Decode(req)
+			_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok","result":{"name":"` + req.Name + `"}}`))
+		case "/v1.0/echo":
+			if r.Header.Get("X-Original-Host") != "api.dingtalk.com" || r.Header.Get("x-acs-dingtalk-access-token") != "user-token" {
+				t.Errorf("unexpected api request: %s %v", r.Header.Get("X-Original-Host"), r.Header)
+			}
+			_, _ = w.Write([]byte(`{"name":"` + r.URL.Query().Get("name") + `"}`))
+		default:
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+
+	resp, err := Do[echoRequest, echoResponse](ctx, client, OAPI("/topapi/echo"), echoRequest{Name: "top"})
+	if err != nil || resp.Name != "top" {
+		t.Fatalf("oapi: resp=%v, err=%v", resp, err)
+	}
+
+	ep := API(http.MethodGet, "/v1.0/echo")
+	ep.AccessToken = "user-token"
+	ep.Query = url.Values{"name": {"api"}}
+	resp, err = Do[any, echoResponse](ctx, client, ep, nil)
+	if err != nil || resp.Name != "api" {
+		t.Fatalf("api: resp=%v, err=%v", resp, err)
+	}
+}
+
+func TestCallRetryAndError(t *testing.T) {
+	var calls, gatewayCalls int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/v1.0/flaky":
+			if atomic.AddInt32(&calls, 1) < 3 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte(`{"code":"Throttling","message":"slow down"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"name":"ok"}`))
+		case "/v1.0/gateway":
+			atomic.AddInt32(&gatewayCalls, 1)
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"code":"ServiceUnavailable","message":"bad gateway"}`))
+		case "/v1.0/denied":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"code":"Forbidden","message":"denied","requestid":"r1"}`))
+		}
+	})
+	ctx := context.Background()
+
+	resp := &echoResponse{}
+	if err := client.Call(ctx, API(http.MethodPost, "/v1.0/flaky"), echoRequest{}, resp); err != nil || resp.Name != "ok" {
+		t.Fatalf("flaky: resp=%v, err=%v", resp, err)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+
+	// 服务端错误时 POST 可能已经被处理，不重试；GET 按重试次数重试
+	if err := client.Call(ctx, API(http.MethodPost, "/v1.0/gateway"), echoRequest{}, nil); ErrorKindOf(err) != ErrorKindServer {
+		t.Errorf("gateway POST err = %v", err)
+	}
+	if n := atomic.LoadInt32(&gatewayCalls); n != 1 {
+		t.Errorf("gateway POST calls = %d, want 1", n)
+	}
+	atomic.StoreInt32(&gatewayCalls, 0)
+	if err := client.Call(ctx, API(http.MethodGet, "/v1.0/gateway"), nil, nil); ErrorKindOf(err) != ErrorKindServer {
+		t.Errorf("gateway GET err = %v", err)
+	}
+	if n := atomic.LoadInt32(&gatewayCalls); n != 3 {
+		t.Errorf("gateway GET calls = %d, want 3", n)
+	}
+
+	err := client.Call(ctx, API(http.MethodPost, "/v1.0/denied"), echoRequest{}, nil)
+	apiErr := &Error{}
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden || apiErr.Code != "Forbidden" || apiErr.RequestID != "r1" {
+		t.Fatalf("denied: err=%v", err)
+	}
+}
+
+func TestCallRefreshesInvalidToken(t *testing.T) {
+	var tokens int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			n := atomic.AddInt32(&tokens, 1)
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"token-` + string(rune('0'+n)) + `","expires_in":7200}`))
+		case "/topapi/echo":
+			if r.URL.Query().Get("access_token") == "token-1" {
+				_, _ = w.Write([]byte(`{"errcode":40014,"errmsg":"invalid access_token"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"errcode":0,"result":{"name":"refreshed"}}`))
+		}
+	})
+	resp, err := Do[echoRequest, echoResponse](context.Background(), client, OAPI("/topapi/echo"), echoRequest{})
+	if err != nil || resp.Name != "refreshed" {
+		t.Fatalf("resp=%v, err=%v", resp, err)
+	}
+	if tokens != 2 {
+		t.Errorf("gettoken calls = %d, want 2", tokens)
+	}
+}
+
+func TestErrorKind(t *testing.T) {
+	cases := []struct {
+		err  error
+		want ErrorKind
+	}{
+		{newTopError(200, 40014, "invalid access_token", ""), ErrorKindAuth},
+		{newTopError(200, 60011, "no permission", ""), ErrorKindPermission},
+		{newTopError(200, 90018, "too frequent", ""), ErrorKindRateLimited},
+		{&Error{StatusCode: 403, Code: "Forbidden.AccessDenied.AccessTokenPermissionDenied"}, ErrorKindPermission},
+		{&Error{StatusCode: 400, Code: "InvalidParameter.UnionId"}, ErrorKindInvalidParameter},
+		{&Error{StatusCode: 404, Code: "groupNotExist"}, ErrorKindNotFound},
+		{&Error{StatusCode: 503}, ErrorKindServer},
+		{ErrRateLimited, ErrorKindRateLimited},
+		{errors.New("dial tcp: timeout"), ErrorKindUnknown},
+	}
+	for _, c := range cases {
+		if got := ErrorKindOf(c.err); got != c.want {
+			t.Errorf("%v: got %s, want %s", c.err, got, c.want)
+		}
+	}
+	if !IsNotFound(&Error{StatusCode: 404}) || IsNotFound(nil) {
+		t.Errorf("IsNotFound mismatch")
+	}
+}
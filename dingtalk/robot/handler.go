@@ -0,0 +1,150 @@
+package robot
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/chzealot/gobase/logger"
+)
+
+// MaxTimestampSkew 回调 timestamp 与本地时间允许的最大偏差，钉钉要求为 1 小时
+const MaxTimestampSkew = time.Hour
+
+const maxBodySize = 1 << 20
+
+var (
+	ErrInvalidSign    = errors.New("robot: invalid sign")
+	ErrStaleTimestamp = errors.New("robot: stale timestamp")
+	ErrSessionExpired = errors.New("robot: session webhook expired")
+)
+
+// VerifySign 校验回调 header 中的 timestamp 和 sign，secret 为应用的 ClientSecret
+func VerifySign(timestamp, sign, secret string, now time.Time) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrStaleTimestamp
+	}
+	skew := now.Sub(time.UnixMilli(ts))
+	if skew > MaxTimestampSkew || skew < -MaxTimestampSkew {
+		return ErrStaleTimestamp
+	}
+	if subtle.ConstantTimeCompare([]byte(Sign(timestamp, secret)), []byte(sign)) != 1 {
+		return ErrInvalidSign
+	}
+	return nil
+}
+
+// Reply 通过 sessionWebhook 回复当前会话，过期后发送会返回 ErrSessionExpired
+type Reply struct {
+	webhook  *Webhook
+	expireAt time.Time
+	senderID string
+}
+
+// NewReply 根据消息中的 sessionWebhook 创建 Reply，Stream 模式下也可以使用
+func NewReply(msg *Message) *Reply {
+	return &Reply{
+		webhook:  NewWebhook(msg.SessionWebhook, ""),
+		expireAt: time.UnixMilli(msg.SessionWebhookExpiredTime),
+		senderID: msg.SenderStaffID,
+	}
+}
+
+func (r *Reply) ExpireAt() time.Time {
+	return r.expireAt
+}
+
+func (r *Reply) check() error {
+	if r.webhook.URL == "" || time.Now().After(r.expireAt) {
+		return ErrSessionExpired
+	}
+	return nil
+}
+
+// Text 回复文本，atUserIds 为需要 @ 的 userId
+func (r *Reply) Text(ctx context.Context, content string, atUserIds ...string) error {
+	if err := r.check(); err != nil {
+		return err
+	}
+	var at *At
+	if len(atUserIds) > 0 {
+		at = &At{AtUserIds: atUserIds}
+	}
+	return r.webhook.SendText(ctx, content, at)
+}
+
+// TextToSender 回复文本并 @ 发送者
+func (r *Reply) TextToSender(ctx context.Context, content string) error {
+	if r.senderID == "" {
+		return r.Text(ctx, content)
+	}
+	return r.Text(ctx, content, r.senderID)
+}
+
+func (r *Reply) Markdown(ctx context.Context, title, text string) error {
+	if err := r.check(); err != nil {
+		return err
+	}
+	return r.webhook.SendMarkdown(ctx, title, text, nil)
+}
+
+func (r *Reply) ActionCard(ctx context.Context, card *ActionCard) error {
+	if err := r.check(); err != nil {
+		return err
+	}
+	return r.webhook.SendActionCard(ctx, card)
+}
+
+type HandlerFunc func(ctx context.Context, msg *Message, reply *Reply) error
+
+// Handler 接收机器人 HTTP 回调消息，验签通过后调用 HandlerFunc。
+// 钉钉要求回调尽快返回，耗时的处理应在 HandlerFunc 中异步执行，Reply 在 sessionWebhook 过期前都可用
+type Handler struct {
+	secret string
+	fn     HandlerFunc
+}
+
+// NewHandler secret 为应用的 ClientSecret
+func NewHandler(secret string, fn HandlerFunc) *Handler {
+	return &Handler{secret: secret, fn: fn}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	ctx := r.Context()
+	if err := VerifySign(r.Header.Get("timestamp"), r.Header.Get("sign"), h.secret, time.Now()); err != nil {
+		logger.WarnwCtx(ctx, "robot.Handler, verify sign failed", "error", err)
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	msg, err := ParseMessage(body)
+	if err != nil {
+		logger.WarnwCtx(ctx, "robot.Handler, parse message failed", "error", err)
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if logger.GetTraceID(ctx) == "" {
+		ctx = logger.WithTraceID(ctx, msg.MsgID)
+	}
+	if err = h.fn(ctx, msg, NewReply(msg)); err != nil {
+		logger.ErrorwCtx(ctx, "robot.Handler, handle message failed",
+			"msgId", msg.MsgID, "msgType", msg.MsgType, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write([]byte("{}"))
+}
@@ -0,0 +1,139 @@
+package robot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	MsgTypeText     = "text"
+	MsgTypeRichText = "richText"
+	MsgTypePicture  = "picture"
+	MsgTypeAudio    = "audio"
+	MsgTypeFile     = "file"
+	MsgTypeVideo    = "video"
+)
+
+const (
+	ConversationTypePrivate = "1"
+	ConversationTypeGroup   = "2"
+)
+
+type AtUser struct {
+	DingtalkID string `json:"dingtalkId"`
+	StaffID    string `json:"staffId"`
+}
+
+type TextContent struct {
+	Content string `json:"content"`
+}
+
+type RichTextItem struct {
+	Type                string `json:"type"`
+	Text                string `json:"text"`
+	DownloadCode        string `json:"downloadCode"`
+	PictureDownloadCode string `json:"pictureDownloadCode"`
+}
+
+type RichTextContent struct {
+	RichText []RichTextItem `json:"richText"`
+}
+
+type PictureContent struct {
+	DownloadCode        string `json:"downloadCode"`
+	PictureDownloadCode string `json:"pictureDownloadCode"`
+}
+
+type AudioContent struct {
+	Duration     int64  `json:"duration"`
+	DownloadCode string `json:"downloadCode"`
+	Recognition  string `json:"recognition"`
+}
+
+type FileContent struct {
+	SpaceID      string `json:"spaceId"`
+	FileID       string `json:"fileId"`
+	FileName     string `json:"fileName"`
+	DownloadCode string `json:"downloadCode"`
+}
+
+// Message 机器人收到的消息，HTTP 回调和 Stream 模式的结构一致
+// 文档：https://open.dingtalk.com/document/orgapp/receive-message
+type Message struct {
+	MsgID                     string          `json:"msgId"`
+	MsgType                   string          `json:"msgtype"`
+	CreateAt                  int64           `json:"createAt"`
+	ConversationID            string          `json:"conversationId"`
+	ConversationType          string          `json:"conversationType"`
+	ConversationTitle         string          `json:"conversationTitle"`
+	SenderID                  string          `json:"senderId"`
+	SenderNick                string          `json:"senderNick"`
+	SenderStaffID             string          `json:"senderStaffId"`
+	SenderCorpID              string          `json:"senderCorpId"`
+	IsAdmin                   bool            `json:"isAdmin"`
+	ChatbotUserID             string          `json:"chatbotUserId"`
+	ChatbotCorpID             string          `json:"chatbotCorpId"`
+	RobotCode                 string          `json:"robotCode"`
+	IsInAtList                bool            `json:"isInAtList"`
+	AtUsers                   []AtUser        `json:"atUsers"`
+	SessionWebhook            string          `json:"sessionWebhook"`
+	SessionWebhookExpiredTime int64           `json:"sessionWebhookExpiredTime"`
+	Text                      *TextContent    `json:"text"`
+	Content                   json.RawMessage `json:"content"`
+
+	RichText *RichTextContent `json:"-"`
+	Picture  *PictureContent  `json:"-"`
+	Audio    *AudioContent    `json:"-"`
+	File     *FileContent     `json:"-"`
+}
+
+// ParseMessage 解析消息，并按 msgtype 解码 content
+func ParseMessage(data []byte) (*Message, error) {
+	msg := &Message{}
+	if err := json.Unmarshal(data, msg); err != nil {
+		return nil, fmt.Errorf("robot.ParseMessage, invalid json: %w", err)
+	}
+	var target interface{}
+	switch msg.MsgType {
+	case MsgTypeRichText:
+		msg.RichText = &RichTextContent{}
+		target = msg.RichText
+	case MsgTypePicture:
+		msg.Picture = &PictureContent{}
+		target = msg.Picture
+	case MsgTypeAudio:
+		msg.Audio = &AudioContent{}
+		target = msg.Audio
+	case MsgTypeFile:
+		msg.File = &FileContent{}
+		target = msg.File
+	}
+	if target != nil && len(msg.Content) > 0 {
+		if err := json.Unmarshal(msg.Content, target); err != nil {
+			return nil, fmt.Errorf("robot.ParseMessage, invalid %s content: %w", msg.MsgType, err)
+		}
+	}
+	return msg, nil
+}
+
+// PlainText 返回消息中的文本，富文本会拼接所有文本片段，语音返回识别结果
+func (m *Message) PlainText() string {
+	switch {
+	case m.Text != nil:
+		return strings.TrimSpace(m.Text.Content)
+	case m.RichText != nil:
+		var sb strings.Builder
+		for _, item := range m.RichText.RichText {
+			sb.WriteString(item.Text)
+		}
+		return strings.TrimSpace(sb.String())
+	case m.Audio != nil:
+		return m.Audio.Recognition
+	}
+	return ""
+}
+
+func (m *Message) IsGroup() bool {
+	return m.ConversationType == ConversationTypeGroup
+}
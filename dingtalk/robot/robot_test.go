@@ -0,0 +1,107 @@
+package robot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/chzealot/gobase/logger"
+)
+
+const testSecret = "test-client-secret"
+
+func TestVerifySign(t *testing.T) {
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	sign := Sign(timestamp, testSecret)
+
+	if err := VerifySign(timestamp, sign, testSecret, now); err != nil {
+		t.Errorf("VerifySign() error = %v", err)
+	}
+	if err := VerifySign(timestamp, sign, "wrong-secret", now); !errors.Is(err, ErrInvalidSign) {
+		t.Errorf("VerifySign() with wrong secret error = %v, want ErrInvalidSign", err)
+	}
+	if err := VerifySign(timestamp, sign, testSecret, now.Add(2*time.Hour)); !errors.Is(err, ErrStaleTimestamp) {
+		t.Errorf("VerifySign() with stale timestamp error = %v, want ErrStaleTimestamp", err)
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	data := `{"msgtype":"richText","msgId":"m1","conversationType":"2",
+		"content":{"richText":[{"text":"hello "},{"type":"picture","downloadCode":"dc1"},{"text":"world"}]}}`
+	msg, err := ParseMessage([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+	if msg.RichText == nil || len(msg.RichText.RichText) != 3 || msg.RichText.RichText[1].DownloadCode != "dc1" {
+		t.Errorf("RichText = %+v", msg.RichText)
+	}
+	if got := msg.PlainText(); got != "hello world" {
+		t.Errorf("PlainText() = %q, want %q", got, "hello world")
+	}
+	if !msg.IsGroup() {
+		t.Error("IsGroup() = false, want true")
+	}
+
+	msg, err = ParseMessage([]byte(`{"msgtype":"file","content":{"fileName":"a.pdf","downloadCode":"dc2"}}`))
+	if err != nil || msg.File == nil || msg.File.FileName != "a.pdf" {
+		t.Errorf("ParseMessage(file) = %+v, %v", msg, err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	if err := logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff}); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+	var sent []*OutgoingMessage
+	session := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		msg := &OutgoingMessage{}
+		_ = json.NewDecoder(r.Body).Decode(msg)
+		sent = append(sent, msg)
+		_, _ = io.WriteString(w, `{"errcode":0,"errmsg":"ok"}`)
+	}))
+	defer session.Close()
+
+	handler := NewHandler(testSecret, func(ctx context.Context, msg *Message, reply *Reply) error {
+		return reply.TextToSender(ctx, "echo: "+msg.PlainText())
+	})
+
+	post := func(expiredAt time.Time, sign string) int {
+		body := fmt.Sprintf(`{"msgtype":"text","msgId":"m1","senderStaffId":"u1","text":{"content":" ping "},
+			"sessionWebhook":%q,"sessionWebhookExpiredTime":%d}`, session.URL, expiredAt.UnixMilli())
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		if sign == "" {
+			sign = Sign(timestamp, testSecret)
+		}
+		r := httptest.NewRequest(http.MethodPost, "/robot", bytes.NewBufferString(body))
+		r.Header.Set("timestamp", timestamp)
+		r.Header.Set("sign", sign)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	if code := post(time.Now().Add(time.Hour), ""); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if len(sent) != 1 || sent[0].Text.Content != "echo: ping" || sent[0].At.AtUserIds[0] != "u1" {
+		t.Errorf("sent = %+v", sent)
+	}
+	if code := post(time.Now().Add(time.Hour), "invalid"); code != http.StatusForbidden {
+		t.Errorf("invalid sign status = %d, want 403", code)
+	}
+	if code := post(time.Now().Add(-time.Minute), ""); code != http.StatusInternalServerError {
+		t.Errorf("expired session status = %d, want 500", code)
+	}
+	if len(sent) != 1 {
+		t.Errorf("expired session should not send, sent = %d", len(sent))
+	}
+}
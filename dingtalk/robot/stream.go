@@ -0,0 +1,19 @@
+package robot
+
+import (
+	"context"
+
+	"github.com/chzealot/gobase/dingtalk/stream"
+)
+
+// StreamHandler 将 Stream 模式推送的机器人消息交给 HandlerFunc，
+// 注册方式：streamClient.OnRobotMessage(robot.StreamHandler(fn))
+func StreamHandler(fn HandlerFunc) stream.Handler {
+	return func(ctx context.Context, frame *stream.DataFrame) (interface{}, error) {
+		msg, err := ParseMessage([]byte(frame.Data))
+		if err != nil {
+			return nil, err
+		}
+		return nil, fn(ctx, msg, NewReply(msg))
+	}
+}
@@ -0,0 +1,131 @@
+package robot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	url2 "net/url"
+	"strconv"
+	"time"
+)
+
+const defaultTimeout = time.Second * 60
+
+type At struct {
+	AtUserIds []string `json:"atUserIds,omitempty"`
+	AtMobiles []string `json:"atMobiles,omitempty"`
+	IsAtAll   bool     `json:"isAtAll,omitempty"`
+}
+
+type Markdown struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+type ActionCardButton struct {
+	Title     string `json:"title"`
+	ActionURL string `json:"actionURL"`
+}
+
+// ActionCard 卡片消息，设置 SingleTitle/SingleURL 为整体跳转，设置 Buttons 为独立跳转
+type ActionCard struct {
+	Title          string             `json:"title"`
+	Text           string             `json:"text"`
+	SingleTitle    string             `json:"singleTitle,omitempty"`
+	SingleURL      string             `json:"singleURL,omitempty"`
+	BtnOrientation string             `json:"btnOrientation,omitempty"`
+	Buttons        []ActionCardButton `json:"btns,omitempty"`
+}
+
+// OutgoingMessage 通过 webhook 发送的消息
+type OutgoingMessage struct {
+	MsgType    string       `json:"msgtype"`
+	Text       *TextContent `json:"text,omitempty"`
+	Markdown   *Markdown    `json:"markdown,omitempty"`
+	ActionCard *ActionCard  `json:"actionCard,omitempty"`
+	At         *At          `json:"at,omitempty"`
+}
+
+type webhookResponse struct {
+	ErrorCode    int    `json:"errcode"`
+	ErrorMessage string `json:"errmsg"`
+}
+
+// Webhook 通过 webhook 地址发送消息，用于自定义机器人和回复 sessionWebhook
+type Webhook struct {
+	URL string
+	// Secret 自定义机器人开启加签时的密钥，sessionWebhook 不需要
+	Secret string
+}
+
+func NewWebhook(url, secret string) *Webhook {
+	return &Webhook{URL: url, Secret: secret}
+}
+
+func (w *Webhook) SendText(ctx context.Context, content string, at *At) error {
+	return w.Send(ctx, &OutgoingMessage{MsgType: "text", Text: &TextContent{Content: content}, At: at})
+}
+
+func (w *Webhook) SendMarkdown(ctx context.Context, title, text string, at *At) error {
+	return w.Send(ctx, &OutgoingMessage{MsgType: "markdown", Markdown: &Markdown{Title: title, Text: text}, At: at})
+}
+
+func (w *Webhook) SendActionCard(ctx context.Context, card *ActionCard) error {
+	return w.Send(ctx, &OutgoingMessage{MsgType: "actionCard", ActionCard: card})
+}
+
+func (w *Webhook) Send(ctx context.Context, msg *OutgoingMessage) error {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/custom-robot-access
+	u, err := url2.Parse(w.URL)
+	if err != nil {
+		return err
+	}
+	if w.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().UnixMilli(), 10)
+		query := u.Query()
+		query.Set("timestamp", timestamp)
+		query.Set("sign", Sign(timestamp, w.Secret))
+		u.RawQuery = query.Encode()
+	}
+	reqBytes, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return err
+	}
+	r.Header.Add("Content-Type", "application/json")
+	client := http.Client{Timeout: defaultTimeout}
+	res, err := client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	respBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	resp := &webhookResponse{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return fmt.Errorf("robot.Webhook, status=%d, invalid response: %w", res.StatusCode, err)
+	}
+	if res.StatusCode != http.StatusOK || resp.ErrorCode != 0 {
+		return fmt.Errorf("robot.Webhook, status=%d, errcode=%d, errmsg=%s", res.StatusCode, resp.ErrorCode, resp.ErrorMessage)
+	}
+	return nil
+}
+
+// Sign 计算签名：base64(HmacSHA256(timestamp + "\n" + secret))，
+// 自定义机器人加签和机器人回调验签使用相同的算法
+func Sign(timestamp, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,93 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/duke-git/lancet/v2/slice"
+)
+
+// CreateSceneGroup 基于群模板创建场景群，返回 openConversationId
+func (c *Client) CreateSceneGroup(ctx context.Context, req *models.CreateSceneGroupRequest) (*models.CreateSceneGroupResponse, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/create-a-scene-group-v2
+	if req == nil || req.Title == "" || req.TemplateID == "" || req.OwnerUserID == "" {
+		return nil, errors.New("dingtalk.CreateSceneGroup, title, templateId and ownerUserId are required")
+	}
+	resp := &models.CreateSceneGroupResponse{}
+	if err := c.doTopApi(ctx, "/topapi/im/chat/scenegroup/create", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateChat 创建不使用模板的普通群，ownerUserId 不在 userIds 中时会自动加入
+func (c *Client) CreateChat(ctx context.Context, name, ownerUserId string, userIds []string) (*models.CreateChatResponse, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/create-group-session
+	if name == "" || ownerUserId == "" {
+		return nil, errors.New("dingtalk.CreateChat, name and ownerUserId are required")
+	}
+	members := userIds
+	if !slice.Contain(members, ownerUserId) {
+		members = append([]string{ownerUserId}, members...)
+	}
+	req := models.CreateChatRequest{Name: name, Owner: ownerUserId, UserIdList: members}
+	resp := &models.CreateChatResponse{}
+	if err := c.doTopApi(ctx, "/chat/create", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// AddSceneGroupMembers 添加场景群成员
+func (c *Client) AddSceneGroupMembers(ctx context.Context, openConversationId string, userIds []string) error {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/add-members-of-scenario-group-v2
+	req := models.SceneGroupMembersRequest{OpenConversationID: openConversationId, UserIds: strings.Join(userIds, ",")}
+	return c.doTopApi(ctx, "/topapi/im/chat/scenegroup/member/add", req, nil)
+}
+
+// RemoveSceneGroupMembers 删除场景群成员
+func (c *Client) RemoveSceneGroupMembers(ctx context.Context, openConversationId string, userIds []string) error {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/delete-members-of-scenario-group-v2
+	req := models.SceneGroupMembersRequest{OpenConversationID: openConversationId, UserIds: strings.Join(userIds, ",")}
+	return c.doTopApi(ctx, "/topapi/im/chat/scenegroup/member/delete", req, nil)
+}
+
+// UpdateSceneGroup 更新场景群的标题、群主、头像
+func (c *Client) UpdateSceneGroup(ctx context.Context, req *models.UpdateSceneGroupRequest) error {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/update-group-v2
+	if req == nil || req.OpenConversationID == "" {
+		return errors.New("dingtalk.UpdateSceneGroup, openConversationId is required")
+	}
+	return c.doTopApi(ctx, "/topapi/im/chat/scenegroup/update", req, nil)
+}
+
+// UpdateSceneGroupTitle 修改群名称
+func (c *Client) UpdateSceneGroupTitle(ctx context.Context, openConversationId, title string) error {
+	return c.UpdateSceneGroup(ctx, &models.UpdateSceneGroupRequest{OpenConversationID: openConversationId, Title: title})
+}
+
+// TransferSceneGroupOwner 转让群主，新群主需要是群成员
+func (c *Client) TransferSceneGroupOwner(ctx context.Context, openConversationId, ownerUserId string) error {
+	return c.UpdateSceneGroup(ctx, &models.UpdateSceneGroupRequest{OpenConversationID: openConversationId, OwnerUserID: ownerUserId})
+}
+
+// GetSceneGroup 根据 openConversationId 获取场景群信息
+func (c *Client) GetSceneGroup(ctx context.Context, openConversationId string) (*models.SceneGroup, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/queries-the-basic-information-of-a-scenario-group
+	req := map[string]string{"open_conversation_id": openConversationId}
+	resp := &models.SceneGroup{}
+	if err := c.doTopApi(ctx, "/topapi/im/chat/scenegroup/get", req, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// InstallGroupRobot 将机器人添加到群中，之后可以通过该机器人在群内发送消息
+func (c *Client) InstallGroupRobot(ctx context.Context, openConversationId, robotCode string) error {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/add-a-robot-to-a-scene-group
+	req := models.InstallGroupRobotRequest{RobotCode: robotCode, OpenConversationID: openConversationId}
+	return c.doApi(ctx, http.MethodPost, "/v1.0/im/chat/scenegroups/robots", nil, req, nil)
+}
@@ -0,0 +1,96 @@
+package dingtalk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+func TestSceneGroup(t *testing.T) {
+	var created models.CreateSceneGroupRequest
+	var chat models.CreateChatRequest
+	members := map[string]models.SceneGroupMembersRequest{}
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/topapi/im/chat/scenegroup/create":
+			_ = json.NewDecoder(r.Body).Decode(&created)
+			_, _ = w.Write([]byte(`{"errcode":0,"result":{"open_conversation_id":"cid1","chat_id":"chat1"}}`))
+		case "/chat/create":
+			chat = models.CreateChatRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&chat)
+			_, _ = w.Write([]byte(`{"errcode":0,"chatid":"chat2","openConversationId":"cid2"}`))
+		case "/topapi/im/chat/scenegroup/member/add", "/topapi/im/chat/scenegroup/member/delete":
+			req := models.SceneGroupMembersRequest{}
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			members[r.URL.Path] = req
+			if req.OpenConversationID != "cid1" {
+				_, _ = w.Write([]byte(`{"errcode":4000003,"errmsg":"群不存在"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"errcode":0,"result":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	if _, err := client.CreateSceneGroup(ctx, &models.CreateSceneGroupRequest{Title: "项目群"}); err == nil {
+		t.Error("CreateSceneGroup() without templateId error = nil")
+	}
+	group, err := client.CreateSceneGroup(ctx, &models.CreateSceneGroupRequest{
+		Title: "项目群", TemplateID: "tpl1", OwnerUserID: "u1", UserIds: "u1,u2",
+	})
+	if err != nil || group.OpenConversationID != "cid1" || group.ChatID != "chat1" {
+		t.Fatalf("CreateSceneGroup() = %+v, %v", group, err)
+	}
+	if created.TemplateID != "tpl1" || created.OwnerUserID != "u1" || created.UserIds != "u1,u2" {
+		t.Errorf("create request = %+v", created)
+	}
+
+	// 群主不在成员中时放在第一位，已在成员中时保持原顺序且不重复
+	tests := []struct {
+		userIds []string
+		want    []string
+	}{
+		{[]string{"u2", "u3"}, []string{"u1", "u2", "u3"}},
+		{[]string{"u2", "u1"}, []string{"u2", "u1"}},
+		{nil, []string{"u1"}},
+	}
+	for _, tt := range tests {
+		resp, err := client.CreateChat(ctx, "普通群", "u1", tt.userIds)
+		if err != nil || resp.OpenConversationID != "cid2" {
+			t.Fatalf("CreateChat(%v) = %+v, %v", tt.userIds, resp, err)
+		}
+		if chat.Owner != "u1" || !reflect.DeepEqual(chat.UserIdList, tt.want) {
+			t.Errorf("CreateChat(%v) request = %+v, want members %v", tt.userIds, chat, tt.want)
+		}
+	}
+	if _, err := client.CreateChat(ctx, "普通群", "", nil); err == nil {
+		t.Error("CreateChat() without owner error = nil")
+	}
+
+	if err := client.AddSceneGroupMembers(ctx, "cid1", []string{"u3", "u4"}); err != nil {
+		t.Errorf("AddSceneGroupMembers() error = %v", err)
+	}
+	if err := client.RemoveSceneGroupMembers(ctx, "cid1", []string{"u2"}); err != nil {
+		t.Errorf("RemoveSceneGroupMembers() error = %v", err)
+	}
+	if got := members["/topapi/im/chat/scenegroup/member/add"]; got.UserIds != "u3,u4" {
+		t.Errorf("add members request = %+v", got)
+	}
+	if got := members["/topapi/im/chat/scenegroup/member/delete"]; got.UserIds != "u2" {
+		t.Errorf("remove members request = %+v", got)
+	}
+	err = client.AddSceneGroupMembers(ctx, "missing", []string{"u3"})
+	apiErr := &Error{}
+	if !errors.As(err, &apiErr) || apiErr.Code != "4000003" {
+		t.Errorf("AddSceneGroupMembers(missing) error = %v", err)
+	}
+}
@@ -0,0 +1,490 @@
+package stream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	url2 "net/url"
+	"sync"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk"
+	"github.com/chzealot/gobase/logger"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultGatewayURL = "https://api.dingtalk.com/v1.0/gateway/connections/open"
+	defaultUserAgent  = "gobase-dingtalk-stream/1.0"
+	defaultTimeout    = time.Second * 60
+	// defaultShutdownTimeout 关闭时等待正在执行的 Handler 回写 ACK 的最长时间
+	defaultShutdownTimeout = time.Second * 10
+)
+
+var errDisconnect = errors.New("stream: disconnect requested by server")
+
+// Handler 处理一个数据帧。EVENT 帧返回 error 时会 ACK 为 LATER 由服务端稍后重推；
+// CALLBACK 帧的返回值会作为回调结果回传给钉钉
+type Handler func(ctx context.Context, frame *DataFrame) (interface{}, error)
+
+type Option func(*Client)
+
+// WithGatewayURL 设置注册连接的网关地址，主要用于测试
+func WithGatewayURL(url string) Option {
+	return func(c *Client) {
+		c.gatewayURL = url
+	}
+}
+
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
+// WithBackoff 设置断线重连的退避区间
+func WithBackoff(min, max time.Duration) Option {
+	return func(c *Client) {
+		c.minBackoff = min
+		c.maxBackoff = max
+	}
+}
+
+// WithKeepAlive 设置 WebSocket ping 间隔，超过两个间隔没有收到任何数据即认为连接已断开
+func WithKeepAlive(interval time.Duration) Option {
+	return func(c *Client) {
+		c.keepAlive = interval
+	}
+}
+
+// WithShutdownTimeout 设置关闭时等待正在执行的 Handler 回写 ACK 的最长时间，默认 10 秒，
+// 超时后取消 Handler 的 ctx 并关闭连接，未 ACK 的消息由服务端重推
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.shutdownTimeout = timeout
+	}
+}
+
+// Client 钉钉 Stream 模式客户端，通过 WebSocket 长连接接收事件、机器人消息和卡片回传
+type Client struct {
+	clientID        string
+	clientSecret    string
+	gatewayURL      string
+	userAgent       string
+	minBackoff      time.Duration
+	maxBackoff      time.Duration
+	keepAlive       time.Duration
+	shutdownTimeout time.Duration
+	httpClient      *http.Client
+	dialer          *websocket.Dialer
+
+	mutex            sync.RWMutex
+	eventHandlers    map[string]Handler
+	callbackHandlers map[string]Handler
+
+	inflight sync.WaitGroup
+}
+
+func NewClient(client *dingtalk.Client, opts ...Option) *Client {
+	c := &Client{
+		clientID:         client.ClientID,
+		clientSecret:     client.ClientSecret,
+		gatewayURL:       defaultGatewayURL,
+		userAgent:        defaultUserAgent,
+		minBackoff:       time.Second,
+		maxBackoff:       time.Minute,
+		keepAlive:        time.Second * 30,
+		shutdownTimeout:  defaultShutdownTimeout,
+		httpClient:       &http.Client{Timeout: defaultTimeout},
+		dialer:           &websocket.Dialer{HandshakeTimeout: time.Second * 10, Proxy: http.ProxyFromEnvironment},
+		eventHandlers:    make(map[string]Handler),
+		callbackHandlers: make(map[string]Handler),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// OnEvent 注册事件处理函数，eventType 为 TopicAllEvents 时处理所有未单独注册的事件
+func (c *Client) OnEvent(eventType string, h Handler) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.eventHandlers[eventType] = h
+}
+
+// OnCallback 注册回调处理函数，topic 如 TopicRobotMessage、TopicCardCallback
+func (c *Client) OnCallback(topic string, h Handler) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.callbackHandlers[topic] = h
+}
+
+func (c *Client) OnRobotMessage(h Handler) {
+	c.OnCallback(TopicRobotMessage, h)
+}
+
+func (c *Client) OnCardCallback(h Handler) {
+	c.OnCallback(TopicCardCallback, h)
+}
+
+func (c *Client) subscriptions() []openConnectionSubscription {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	subs := make([]openConnectionSubscription, 0, len(c.callbackHandlers)+1)
+	if len(c.eventHandlers) > 0 {
+		// 事件只能按 * 订阅，具体类型在本地分发
+		subs = append(subs, openConnectionSubscription{Type: FrameTypeEvent, Topic: TopicAllEvents})
+	}
+	for topic := range c.callbackHandlers {
+		subs = append(subs, openConnectionSubscription{Type: FrameTypeCallback, Topic: topic})
+	}
+	return subs
+}
+
+// Run 建立连接并持续接收数据，断线后按指数退避自动重连。
+// ctx 结束时不再接收新消息，等待正在执行的 Handler 回写 ACK（最多 shutdownTimeout）后关闭连接并返回 nil，
+// 超时后 Handler 的 ctx 被取消，仍未结束的 Handler 不再等待
+func (c *Client) Run(ctx context.Context) error {
+	if len(c.subscriptions()) == 0 {
+		return errors.New("stream.Client, no handler registered")
+	}
+	handlerCtx, cancelHandlers := c.handlerContext(ctx)
+	defer cancelHandlers()
+	backoff := c.minBackoff
+	for {
+		connected, err := c.connectAndServe(ctx, handlerCtx)
+		if ctx.Err() != nil {
+			c.waitInflight(handlerCtx)
+			logger.Infow("stream.Client, stopped")
+			return nil
+		}
+		if connected {
+			backoff = c.minBackoff
+		}
+		wait := jitter(backoff)
+		logger.Warnw("stream.Client, connection lost, reconnecting",
+			"error", err, "wait", wait.String())
+		select {
+		case <-ctx.Done():
+			c.waitInflight(handlerCtx)
+			logger.Infow("stream.Client, stopped")
+			return nil
+		case <-time.After(wait):
+		}
+		if !connected {
+			backoff *= 2
+			if backoff > c.maxBackoff {
+				backoff = c.maxBackoff
+			}
+		}
+	}
+}
+
+// handlerContext 返回传给 Handler 的 ctx，不随 Run 的 ctx 取消，以便关闭时已收到的消息能处理完，
+// 在 Run 的 ctx 结束 shutdownTimeout 后取消
+func (c *Client) handlerContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	handlerCtx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-handlerCtx.Done():
+			return
+		case <-ctx.Done():
+		}
+		timer := time.NewTimer(c.shutdownTimeout)
+		defer timer.Stop()
+		select {
+		case <-handlerCtx.Done():
+		case <-timer.C:
+			cancel()
+		}
+	}()
+	return handlerCtx, cancel
+}
+
+// waitInflight 等待所有 Handler 结束，handlerCtx 被取消（关闭超时）后不再等待
+func (c *Client) waitInflight(handlerCtx context.Context) {
+	idle := make(chan struct{})
+	go func() {
+		c.inflight.Wait()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+	case <-handlerCtx.Done():
+		logger.Warnw("stream.Client, shutdown timeout, handlers still running",
+			"timeout", c.shutdownTimeout.String())
+	}
+}
+
+func (c *Client) openConnection(ctx context.Context) (string, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/direction/stream-mode-protocol-access-description
+	req := openConnectionRequest{
+		ClientID:      c.clientID,
+		ClientSecret:  c.clientSecret,
+		Subscriptions: c.subscriptions(),
+		UA:            c.userAgent,
+	}
+	reqBytes, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, c.gatewayURL, bytes.NewBuffer(reqBytes))
+	if err != nil {
+		return "", err
+	}
+	r.Header.Add("Content-Type", "application/json")
+	r.Header.Add("Accept", "application/json")
+	res, err := c.httpClient.Do(r)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	respBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("stream.Client, open connection failed, status=%d, body=%s", res.StatusCode, string(respBytes))
+	}
+	resp := &openConnectionResponse{}
+	if err = json.Unmarshal(respBytes, resp); err != nil {
+		return "", err
+	}
+	if resp.Endpoint == "" || resp.Ticket == "" {
+		return "", errors.New("stream.Client, open connection returned empty endpoint or ticket")
+	}
+	endpoint, err := url2.Parse(resp.Endpoint)
+	if err != nil {
+		return "", err
+	}
+	query := endpoint.Query()
+	query.Set("ticket", resp.Ticket)
+	endpoint.RawQuery = query.Encode()
+	return endpoint.String(), nil
+}
+
+// connection 包装单条 WebSocket 连接，gorilla/websocket 不支持并发写
+type connection struct {
+	conn  *websocket.Conn
+	mutex sync.Mutex
+
+	// inflight 该连接上尚未回写 ACK 的 Handler，closing 后不再增加
+	inflightMutex sync.Mutex
+	closing       bool
+	inflight      sync.WaitGroup
+	idle          chan struct{}
+}
+
+// track 登记一个待 ACK 的 Handler，连接关闭中时返回 false
+func (cn *connection) track() bool {
+	cn.inflightMutex.Lock()
+	defer cn.inflightMutex.Unlock()
+	if cn.closing {
+		return false
+	}
+	cn.inflight.Add(1)
+	return true
+}
+
+// drain 停止登记新的 Handler 并等待已登记的 Handler 回写 ACK，超时返回 false，可以重复调用
+func (cn *connection) drain(timeout time.Duration) bool {
+	cn.inflightMutex.Lock()
+	if !cn.closing {
+		cn.closing = true
+		cn.idle = make(chan struct{})
+		go func(idle chan struct{}) {
+			cn.inflight.Wait()
+			close(idle)
+		}(cn.idle)
+	}
+	idle := cn.idle
+	cn.inflightMutex.Unlock()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (cn *connection) writeJSON(v interface{}) error {
+	cn.mutex.Lock()
+	defer cn.mutex.Unlock()
+	return cn.conn.WriteJSON(v)
+}
+
+func (cn *connection) writeControl(messageType int, data []byte) error {
+	return cn.conn.WriteControl(messageType, data, time.Now().Add(time.Second*5))
+}
+
+func (c *Client) connectAndServe(ctx, handlerCtx context.Context) (bool, error) {
+	endpoint, err := c.openConnection(ctx)
+	if err != nil {
+		return false, err
+	}
+	conn, _, err := c.dialer.DialContext(ctx, endpoint, nil)
+	if err != nil {
+		return false, err
+	}
+	cn := &connection{conn: conn}
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		if ctx.Err() != nil {
+			// 读取提前结束时同样等待 ACK 写完再关闭底层连接
+			c.drain(cn)
+		}
+		_ = conn.Close()
+	}()
+	logger.Infow("stream.Client, connected", "endpoint", conn.RemoteAddr().String())
+
+	readTimeout := c.keepAlive * 2
+	_ = conn.SetReadDeadline(time.Now().Add(readTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	})
+
+	go func() {
+		ticker := time.NewTicker(c.keepAlive)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				// 优雅关闭：先等待正在执行的 Handler 回写 ACK，发送 close 帧后不能再写数据帧；
+				// 然后通知服务端并等待对端关闭，最多等待一秒
+				c.drain(cn)
+				_ = cn.writeControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+				return
+			case <-ticker.C:
+				if err := cn.writeControl(websocket.PingMessage, nil); err != nil {
+					_ = conn.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return true, err
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(readTimeout))
+		frame := &DataFrame{}
+		if err = json.Unmarshal(message, frame); err != nil {
+			logger.Warnw("stream.Client, invalid frame", "error", err)
+			continue
+		}
+		if err = c.handleFrame(ctx, handlerCtx, cn, frame); err != nil {
+			return true, err
+		}
+	}
+}
+
+func (c *Client) handleFrame(ctx, handlerCtx context.Context, cn *connection, frame *DataFrame) error {
+	switch frame.Type {
+	case FrameTypeSystem:
+		switch frame.Topic() {
+		case TopicPing:
+			return cn.writeJSON(newResponse(frame, http.StatusOK, "OK", frame.Data))
+		case TopicDisconnect:
+			return errDisconnect
+		default:
+			logger.Debugw("stream.Client, system frame", "topic", frame.Topic())
+			return nil
+		}
+	case FrameTypeEvent, FrameTypeCallback:
+		if ctx.Err() != nil {
+			// 关闭中不再接收新消息，不 ACK 由服务端重推
+			return nil
+		}
+		h := c.lookup(frame)
+		if h == nil {
+			logger.Warnw("stream.Client, no handler", "type", frame.Type, "topic", frame.Topic())
+			return cn.writeJSON(newResponse(frame, http.StatusNotFound, "no handler", ""))
+		}
+		if !cn.track() {
+			return nil
+		}
+		c.inflight.Add(1)
+		go func() {
+			defer c.inflight.Done()
+			defer cn.inflight.Done()
+			resp := c.invoke(handlerCtx, h, frame)
+			if err := cn.writeJSON(resp); err != nil {
+				logger.Warnw("stream.Client, write ack failed",
+					"messageId", frame.MessageID(), "error", err)
+			}
+		}()
+		return nil
+	default:
+		logger.Warnw("stream.Client, unknown frame type", "type", frame.Type)
+		return nil
+	}
+}
+
+// drain 等待连接上的 Handler 回写 ACK，最多等待 shutdownTimeout
+func (c *Client) drain(cn *connection) {
+	if !cn.drain(c.shutdownTimeout) {
+		logger.Warnw("stream.Client, shutdown timeout, closing with pending acks",
+			"timeout", c.shutdownTimeout.String())
+	}
+}
+
+func (c *Client) lookup(frame *DataFrame) Handler {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	if frame.Type == FrameTypeEvent {
+		if h, ok := c.eventHandlers[frame.Header(HeaderEventType)]; ok {
+			return h
+		}
+		return c.eventHandlers[TopicAllEvents]
+	}
+	return c.callbackHandlers[frame.Topic()]
+}
+
+func (c *Client) invoke(handlerCtx context.Context, h Handler, frame *DataFrame) (resp *DataFrameResponse) {
+	ctx := logger.WithTraceID(handlerCtx, frame.MessageID())
+	defer func() {
+		if r := recover(); r != nil {
+			logger.ErrorwCtx(ctx, "stream.Client, handler panic", "topic", frame.Topic(), "panic", r)
+			resp = c.failure(frame, fmt.Errorf("panic: %v", r))
+		}
+	}()
+	result, err := h(ctx, frame)
+	if err != nil {
+		logger.WarnwCtx(ctx, "stream.Client, handler failed", "topic", frame.Topic(), "error", err)
+		return c.failure(frame, err)
+	}
+	if frame.Type == FrameTypeEvent {
+		return newResponse(frame, http.StatusOK, "OK", marshalString(eventAck{Status: EventStatusSuccess}))
+	}
+	return newResponse(frame, http.StatusOK, "OK", marshalString(map[string]interface{}{"response": result}))
+}
+
+func (c *Client) failure(frame *DataFrame, err error) *DataFrameResponse {
+	if frame.Type == FrameTypeEvent {
+		return newResponse(frame, http.StatusOK, "OK",
+			marshalString(eventAck{Status: EventStatusLater, Message: err.Error()}))
+	}
+	return newResponse(frame, http.StatusInternalServerError, err.Error(), "")
+}
+
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d)/2+1))
+}
@@ -0,0 +1,271 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk"
+	"github.com/chzealot/gobase/logger"
+	"github.com/gorilla/websocket"
+)
+
+// stubGateway 模拟钉钉 Stream 网关：注册连接后通过 WebSocket 下发数据帧
+type stubGateway struct {
+	server      *httptest.Server
+	connections int32
+	acks        chan *DataFrameResponse
+	script      func(conn *websocket.Conn, n int32, closed <-chan struct{})
+}
+
+func newStubGateway(t *testing.T, script func(conn *websocket.Conn, n int32, closed <-chan struct{})) *stubGateway {
+	g := &stubGateway{acks: make(chan *DataFrameResponse, 16), script: script}
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1.0/gateway/connections/open", func(w http.ResponseWriter, r *http.Request) {
+		req := &openConnectionRequest{}
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil || req.ClientID != "test-id" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(openConnectionResponse{
+			Endpoint: "ws" + strings.TrimPrefix(g.server.URL, "http") + "/connect",
+			Ticket:   "test-ticket",
+		})
+	})
+	mux.HandleFunc("/connect", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("ticket") != "test-ticket" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		n := atomic.AddInt32(&g.connections, 1)
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				resp := &DataFrameResponse{}
+				if err := conn.ReadJSON(resp); err != nil {
+					return
+				}
+				g.acks <- resp
+			}
+		}()
+		g.script(conn, n, closed)
+	})
+	g.server = httptest.NewServer(mux)
+	return g
+}
+
+func (g *stubGateway) waitAck(t *testing.T) *DataFrameResponse {
+	select {
+	case ack := <-g.acks:
+		return ack
+	case <-time.After(time.Second * 5):
+		t.Fatal("timeout waiting for ack")
+		return nil
+	}
+}
+
+func frame(frameType, topic, messageId string, headers map[string]string, data string) *DataFrame {
+	h := map[string]string{HeaderTopic: topic, HeaderMessageID: messageId}
+	for k, v := range headers {
+		h[k] = v
+	}
+	return &DataFrame{SpecVersion: "1.0", Type: frameType, Headers: h, Data: data}
+}
+
+func TestClientRun(t *testing.T) {
+	if err := logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff}); err != nil {
+		t.Fatalf("Failed to initialize logger: %v", err)
+	}
+
+	reconnected := make(chan struct{})
+	g := newStubGateway(t, func(conn *websocket.Conn, n int32, closed <-chan struct{}) {
+		if n == 1 {
+			_ = conn.WriteJSON(frame(FrameTypeSystem, TopicPing, "m-ping", nil, `{"opaque":"1"}`))
+			_ = conn.WriteJSON(frame(FrameTypeCallback, TopicRobotMessage, "m-robot", nil, `{"text":{"content":"hi"}}`))
+			_ = conn.WriteJSON(frame(FrameTypeEvent, "*", "m-event", map[string]string{HeaderEventType: "user_add_org"}, `{}`))
+			time.Sleep(time.Millisecond * 200)
+			_ = conn.WriteJSON(frame(FrameTypeSystem, TopicDisconnect, "m-bye", nil, ""))
+			time.Sleep(time.Millisecond * 200)
+			return
+		}
+		close(reconnected)
+		<-closed
+	})
+	defer g.server.Close()
+
+	client := NewClient(dingtalk.NewDingTalkClient("test-id", "test-secret"),
+		WithGatewayURL(g.server.URL+"/v1.0/gateway/connections/open"),
+		WithBackoff(time.Millisecond*10, time.Millisecond*50),
+		WithKeepAlive(time.Second))
+	client.OnRobotMessage(func(ctx context.Context, f *DataFrame) (interface{}, error) {
+		if logger.GetTraceID(ctx) != f.MessageID() {
+			t.Errorf("trace id = %q, want %q", logger.GetTraceID(ctx), f.MessageID())
+		}
+		return map[string]string{"reply": "ok"}, nil
+	})
+	client.OnEvent("user_add_org", func(ctx context.Context, f *DataFrame) (interface{}, error) {
+		return nil, errors.New("retry later")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- client.Run(ctx) }()
+
+	acks := map[string]*DataFrameResponse{}
+	for i := 0; i < 3; i++ {
+		ack := g.waitAck(t)
+		acks[ack.Headers[HeaderMessageID]] = ack
+	}
+	if ack := acks["m-ping"]; ack == nil || ack.Code != 200 || ack.Data != `{"opaque":"1"}` {
+		t.Errorf("ping ack = %+v", ack)
+	}
+	if ack := acks["m-robot"]; ack == nil || ack.Code != 200 || ack.Data != `{"response":{"reply":"ok"}}` {
+		t.Errorf("robot ack = %+v", ack)
+	}
+	if ack := acks["m-event"]; ack == nil || !strings.Contains(ack.Data, EventStatusLater) {
+		t.Errorf("event ack = %+v", ack)
+	}
+
+	select {
+	case <-reconnected:
+	case <-time.After(time.Second * 5):
+		t.Fatal("client did not reconnect after disconnect")
+	}
+
+	cancel()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("Run() = %v, want nil", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Run did not return after cancel")
+	}
+}
+
+func TestClientRunWithoutHandler(t *testing.T) {
+	client := NewClient(dingtalk.NewDingTalkClient("test-id", "test-secret"))
+	if err := client.Run(context.Background()); err == nil {
+		t.Error("Run() without handler should fail")
+	}
+}
+
+func TestClientRunWaitsForInflightAck(t *testing.T) {
+	_ = logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff})
+
+	g := newStubGateway(t, func(conn *websocket.Conn, n int32, closed <-chan struct{}) {
+		_ = conn.WriteJSON(frame(FrameTypeCallback, TopicRobotMessage, "m-slow", nil, `{}`))
+		<-closed
+	})
+	defer g.server.Close()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	client := NewClient(dingtalk.NewDingTalkClient("test-id", "test-secret"),
+		WithGatewayURL(g.server.URL+"/v1.0/gateway/connections/open"),
+		WithKeepAlive(time.Second),
+		WithShutdownTimeout(time.Second*5))
+	client.OnRobotMessage(func(ctx context.Context, f *DataFrame) (interface{}, error) {
+		close(started)
+		<-release
+		return "done", nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- client.Run(ctx) }()
+
+	select {
+	case <-started:
+	case <-time.After(time.Second * 5):
+		t.Fatal("handler not started")
+	}
+	cancel()
+	// 关闭开始后 Handler 仍在执行，ACK 必须在连接关闭前写出
+	time.Sleep(time.Millisecond * 200)
+	close(release)
+
+	ack := g.waitAck(t)
+	if ack.Headers[HeaderMessageID] != "m-slow" || ack.Code != 200 || ack.Data != `{"response":"done"}` {
+		t.Errorf("ack = %+v", ack)
+	}
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("Run() = %v, want nil", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Run did not return after cancel")
+	}
+}
+
+func TestClientRunShutdownTimeout(t *testing.T) {
+	_ = logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff})
+
+	g := newStubGateway(t, func(conn *websocket.Conn, n int32, closed <-chan struct{}) {
+		_ = conn.WriteJSON(frame(FrameTypeCallback, TopicRobotMessage, "m-ctx", nil, `{}`))
+		_ = conn.WriteJSON(frame(FrameTypeEvent, "user_add_org", "m-stuck", map[string]string{HeaderEventType: "user_add_org"}, `{}`))
+		<-closed
+	})
+	defer g.server.Close()
+
+	started := make(chan struct{}, 2)
+	cancelled := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	client := NewClient(dingtalk.NewDingTalkClient("test-id", "test-secret"),
+		WithGatewayURL(g.server.URL+"/v1.0/gateway/connections/open"),
+		WithKeepAlive(time.Second),
+		WithShutdownTimeout(time.Millisecond*300))
+	// 遵循 ctx 的 Handler 在关闭超时后收到取消
+	client.OnRobotMessage(func(ctx context.Context, f *DataFrame) (interface{}, error) {
+		started <- struct{}{}
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	})
+	// 忽略 ctx 的 Handler 不会阻塞 Run 返回
+	client.OnEvent(TopicAllEvents, func(ctx context.Context, f *DataFrame) (interface{}, error) {
+		started <- struct{}{}
+		<-release
+		return nil, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() { result <- client.Run(ctx) }()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-started:
+		case <-time.After(time.Second * 5):
+			t.Fatal("handlers not started")
+		}
+	}
+	cancel()
+	select {
+	case err := <-result:
+		if err != nil {
+			t.Errorf("Run() = %v, want nil", err)
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatal("Run did not return within shutdown timeout")
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("handler ctx not cancelled after shutdown timeout")
+	}
+}
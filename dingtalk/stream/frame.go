@@ -0,0 +1,120 @@
+package stream
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+const (
+	FrameTypeSystem   = "SYSTEM"
+	FrameTypeEvent    = "EVENT"
+	FrameTypeCallback = "CALLBACK"
+)
+
+const (
+	TopicPing       = "ping"
+	TopicDisconnect = "disconnect"
+
+	// TopicRobotMessage 机器人接收消息
+	TopicRobotMessage = "/v1.0/im/bot/messages/get"
+	// TopicCardCallback 互动卡片回传
+	TopicCardCallback = "/v1.0/card/instances/callback"
+	// TopicAllEvents 订阅所有事件
+	TopicAllEvents = "*"
+)
+
+const (
+	HeaderTopic       = "topic"
+	HeaderMessageID   = "messageId"
+	HeaderContentType = "contentType"
+	HeaderEventType   = "eventType"
+	HeaderEventID     = "eventId"
+	HeaderEventCorpID = "eventCorpId"
+	HeaderEventBornAt = "eventBornTime"
+)
+
+const (
+	EventStatusSuccess = "SUCCESS"
+	EventStatusLater   = "LATER"
+)
+
+// DataFrame 网关推送的数据帧
+type DataFrame struct {
+	SpecVersion string            `json:"specVersion"`
+	Type        string            `json:"type"`
+	Time        int64             `json:"time"`
+	Headers     map[string]string `json:"headers"`
+	Data        string            `json:"data"`
+}
+
+func (f *DataFrame) Header(key string) string {
+	if f.Headers == nil {
+		return ""
+	}
+	return f.Headers[key]
+}
+
+func (f *DataFrame) Topic() string {
+	return f.Header(HeaderTopic)
+}
+
+func (f *DataFrame) MessageID() string {
+	return f.Header(HeaderMessageID)
+}
+
+// EventBornTime 事件产生时间，毫秒时间戳
+func (f *DataFrame) EventBornTime() int64 {
+	ts, _ := strconv.ParseInt(f.Header(HeaderEventBornAt), 10, 64)
+	return ts
+}
+
+// DataFrameResponse 客户端对数据帧的 ACK
+type DataFrameResponse struct {
+	Code    int               `json:"code"`
+	Headers map[string]string `json:"headers"`
+	Message string            `json:"message"`
+	Data    string            `json:"data"`
+}
+
+func newResponse(frame *DataFrame, code int, message string, data string) *DataFrameResponse {
+	return &DataFrameResponse{
+		Code: code,
+		Headers: map[string]string{
+			HeaderContentType: "application/json",
+			HeaderMessageID:   frame.MessageID(),
+		},
+		Message: message,
+		Data:    data,
+	}
+}
+
+type openConnectionSubscription struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic"`
+}
+
+type openConnectionRequest struct {
+	ClientID      string                       `json:"clientId"`
+	ClientSecret  string                       `json:"clientSecret"`
+	Subscriptions []openConnectionSubscription `json:"subscriptions"`
+	UA            string                       `json:"ua"`
+	LocalIP       string                       `json:"localIp"`
+}
+
+type openConnectionResponse struct {
+	Endpoint string `json:"endpoint"`
+	Ticket   string `json:"ticket"`
+}
+
+type eventAck struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+func marshalString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
@@ -0,0 +1,52 @@
+package dingtalk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	url2 "net/url"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+// CreateTodo 以 unionId 的身份创建待办，设置 SourceID 后可以通过 GetTodoBySourceID 查询，
+// 用于与业务系统中的数据关联，DetailUrl 为待办详情的跳转地址
+func (c *Client) CreateTodo(ctx context.Context, unionId string, req *models.CreateTodoTaskRequest) (*models.CreateTodoTaskResponse, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/add-dingtalk-to-do-task
+	if unionId == "" || req == nil || req.Subject == "" {
+		return nil, errors.New("dingtalk.CreateTodo, unionId and subject are required")
+	}
+	ep := API(http.MethodPost, "/v1.0/todo/users/{unionId}/tasks")
+	ep.PathParams = []string{unionId}
+	ep.Query = url2.Values{}
+	ep.Query.Set("operatorId", unionId)
+	return Do[*models.CreateTodoTaskRequest, models.CreateTodoTaskResponse](ctx, c, ep, req)
+}
+
+// GetTodoBySourceID 根据创建时设置的 SourceID 查询 unionId 的待办，不存在时返回的错误满足 IsNotFound
+func (c *Client) GetTodoBySourceID(ctx context.Context, unionId, sourceId string) (*models.TodoTask, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/query-to-do-tasks-based-on-sourceid
+	ep := API(http.MethodGet, "/v1.0/todo/users/{unionId}/tasks/sources/{sourceId}")
+	ep.PathParams = []string{unionId, sourceId}
+	return Do[any, models.TodoTask](ctx, c, ep, nil)
+}
+
+// IterateTodoTasks 遍历 unionId 的企业待办，isDone 为 true 时返回已完成的待办
+func (c *Client) IterateTodoTasks(unionId string, isDone bool) *Pager[models.TodoCard] {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/query-the-to-do-list-of-enterprise-users
+	return NewPager(TokenPages(func(ctx context.Context, nextToken string) ([]models.TodoCard, string, error) {
+		ep := API(http.MethodPost, "/v1.0/todo/users/{unionId}/org/tasks/query")
+		ep.PathParams = []string{unionId}
+		req := models.QueryTodoTasksRequest{NextToken: nextToken, IsDone: isDone}
+		resp := &models.QueryTodoTasksResponse{}
+		if err := c.Call(ctx, ep, req, resp); err != nil {
+			return nil, "", err
+		}
+		return resp.TodoCards, resp.NextToken, nil
+	}))
+}
+
+// ListTodoTasks 获取 unionId 的所有未完成或已完成待办
+func (c *Client) ListTodoTasks(ctx context.Context, unionId string, isDone bool) ([]models.TodoCard, error) {
+	return c.IterateTodoTasks(unionId, isDone).All(ctx)
+}
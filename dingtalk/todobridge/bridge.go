@@ -0,0 +1,163 @@
+// Package todobridge 按规则为需要提前准备的日程创建钉钉待办，待办的 SourceID 由日程 ID 生成，重复执行不会创建重复的待办
+package todobridge
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk"
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/duke-git/lancet/v2/slice"
+)
+
+const sourceIDPrefix = "calendar-event:"
+
+// checklistPattern 匹配描述中的 markdown 任务列表，如 “- [ ] 准备材料”
+var checklistPattern = regexp.MustCompile(`(?m)^\s*[-*]?\s*\[[ xX]?\]\s+\S`)
+
+// Rule 日程匹配规则，第一个匹配的规则决定待办的截止时间和标题
+type Rule struct {
+	Name string
+	// Match 返回 true 时为日程创建待办
+	Match func(event *models.CalendarEvent) bool
+	// DueBefore 待办截止时间为日程开始前多久，截止时间已经过去时使用同步的时间
+	DueBefore time.Duration
+	// Subject 生成待办标题，为 nil 时为 “准备：” 加日程标题
+	Subject func(event *models.CalendarEvent) string
+}
+
+func (r Rule) subject(event *models.CalendarEvent) string {
+	if r.Subject != nil {
+		return r.Subject(event)
+	}
+	return "准备：" + event.Summary
+}
+
+// CategoryRule 匹配带有指定分类的日程
+func CategoryRule(category string, dueBefore time.Duration) Rule {
+	return Rule{
+		Name: "category:" + category,
+		Match: func(event *models.CalendarEvent) bool {
+			return slice.ContainBy(event.Categories, func(c models.EventCategory) bool {
+				return c.DisplayName == category
+			})
+		},
+		DueBefore: dueBefore,
+	}
+}
+
+// ChecklistRule 匹配描述中包含任务列表（- [ ] xxx）的日程
+func ChecklistRule(dueBefore time.Duration) Rule {
+	return Rule{
+		Name: "checklist",
+		Match: func(event *models.CalendarEvent) bool {
+			return checklistPattern.MatchString(event.Description)
+		},
+		DueBefore: dueBefore,
+	}
+}
+
+// SourceID 返回日程对应待办的 SourceID
+func SourceID(event *models.CalendarEvent) string {
+	return sourceIDPrefix + event.ID
+}
+
+// Bridge 扫描日程并创建待办，Client 使用应用 access_token，需要日历读权限和待办写权限
+type Bridge struct {
+	Client *dingtalk.Client
+	Rules  []Rule
+	// DetailURL 返回待办跳转的地址，如业务系统中的日程详情页，为 nil 时使用日程的线上会议链接
+	DetailURL func(event *models.CalendarEvent) string
+	// Now 用于测试，为 nil 时使用 time.Now
+	Now func() time.Time
+}
+
+// SyncResult 一次同步的结果
+type SyncResult struct {
+	// Created 新创建的待办
+	Created []*models.CreateTodoTaskResponse
+	// Existing 已经创建过待办的日程 ID
+	Existing []string
+}
+
+// Sync 扫描 unionId 主日历中 [timeMin, timeMax] 内的日程，为匹配规则且尚未开始的日程创建待办，
+// 出错时返回已完成的部分结果
+func (b *Bridge) Sync(ctx context.Context, unionId string, timeMin, timeMax time.Time) (*SyncResult, error) {
+	if unionId == "" {
+		return nil, errors.New("todobridge.Sync, unionId is required")
+	}
+	now := time.Now()
+	if b.Now != nil {
+		now = b.Now()
+	}
+	result := &SyncResult{}
+	pager := b.Client.IterateCalendarEvents("", unionId, dingtalk.PrimaryCalendarID,
+		timeMin.Format(time.RFC3339), timeMax.Format(time.RFC3339))
+	defer pager.Close()
+	for pager.Next(ctx) {
+		event := pager.Item()
+		start := eventStart(event)
+		if event.Status == "cancelled" || start.IsZero() || start.Before(now) {
+			continue
+		}
+		rule, ok := b.match(event)
+		if !ok {
+			continue
+		}
+		_, err := b.Client.GetTodoBySourceID(ctx, unionId, SourceID(event))
+		if err == nil {
+			result.Existing = append(result.Existing, event.ID)
+			continue
+		}
+		if !dingtalk.IsNotFound(err) {
+			return result, err
+		}
+		todo, err := b.Client.CreateTodo(ctx, unionId, b.newTodo(unionId, event, rule, start, now))
+		if err != nil {
+			return result, err
+		}
+		result.Created = append(result.Created, todo)
+	}
+	return result, pager.Err()
+}
+
+func (b *Bridge) match(event *models.CalendarEvent) (Rule, bool) {
+	for _, rule := range b.Rules {
+		if rule.Match(event) {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
+
+func (b *Bridge) newTodo(unionId string, event *models.CalendarEvent, rule Rule, start, now time.Time) *models.CreateTodoTaskRequest {
+	detailURL := event.OnlineMeetingInfo.Url
+	if b.DetailURL != nil {
+		detailURL = b.DetailURL(event)
+	}
+	// 日程临近时提前量可能已经不够，截止时间不早于当前时间，避免创建即逾期的待办
+	due := start.Add(-rule.DueBefore)
+	if due.Before(now) {
+		due = now
+	}
+	return &models.CreateTodoTaskRequest{
+		SourceID:       SourceID(event),
+		Subject:        rule.subject(event),
+		Description:    event.Description,
+		CreatorID:      unionId,
+		DueTime:        due.UnixMilli(),
+		ExecutorIds:    []string{unionId},
+		ParticipantIds: []string{unionId},
+		DetailUrl:      models.CreateTodoTaskRequestDetailUrl{AppUrl: detailURL, PcUrl: detailURL},
+	}
+}
+
+// eventStart 返回日程开始时间，全天日程为当天零点
+func eventStart(event *models.CalendarEvent) time.Time {
+	if !event.Start.DateTime.IsZero() {
+		return event.Start.DateTime.Time
+	}
+	return event.Start.Date.Time
+}
@@ -0,0 +1,59 @@
+package todobridge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/dingtalktest"
+	"github.com/chzealot/gobase/dingtalk/models"
+	"github.com/chzealot/gobase/logger"
+)
+
+func TestSync(t *testing.T) {
+	_ = logger.InitWithConfig(logger.Config{AppName: "gobase-test", DebugMode: logger.DebugModeOff})
+	s := dingtalktest.NewServer()
+	defer s.Close()
+
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, models.DefaultLocation)
+	at := func(hour int) models.EventTime {
+		return models.EventTime{DateTime: models.Time{Time: now.Add(time.Duration(hour) * time.Hour)}}
+	}
+	review := s.Store.AddEvent("union1", models.CalendarEvent{
+		Summary: "季度评审", Start: at(5), Categories: models.EventCategoryList{{DisplayName: "需准备"}},
+	})
+	s.Store.AddEvent("union1", models.CalendarEvent{Summary: "周会", Start: at(2), Description: "议程：\n- [ ] 更新进度\n- [x] 预订会议室"})
+	s.Store.AddEvent("union1", models.CalendarEvent{Summary: "午餐", Start: at(3), Description: "[链接](https://example.com)"})
+	s.Store.AddEvent("union1", models.CalendarEvent{Summary: "已开始", Start: at(-1), Categories: models.EventCategoryList{{DisplayName: "需准备"}}})
+	s.Store.AddEvent("union1", models.CalendarEvent{Summary: "其他分类", Start: at(4), Categories: models.EventCategoryList{{DisplayName: "需准备材料"}}})
+
+	bridge := &Bridge{
+		Client:    s.Client(),
+		Rules:     []Rule{CategoryRule("需准备", 24*time.Hour), ChecklistRule(30 * time.Minute)},
+		DetailURL: func(event *models.CalendarEvent) string { return "https://example.com/events/" + event.ID },
+		Now:       func() time.Time { return now },
+	}
+	ctx := context.Background()
+	result, err := bridge.Sync(ctx, "union1", now.Add(-2*time.Hour), now.Add(24*time.Hour))
+	if err != nil || len(result.Created) != 2 || len(result.Existing) != 0 {
+		t.Fatalf("first sync: result=%+v, err=%v", result, err)
+	}
+	todos := s.Store.Todos("union1")
+	// 日程 5 小时后开始，提前 24 小时的截止时间已经过去，使用当前时间
+	if len(todos) != 2 || todos[0].SourceID != "calendar-event:"+review || todos[0].Subject != "准备：季度评审" ||
+		todos[0].DueTime != now.UnixMilli() ||
+		todos[0].DetailUrl.PcUrl != "https://example.com/events/"+review {
+		t.Fatalf("todos = %+v", todos)
+	}
+	if todos[1].DueTime != now.Add(90*time.Minute).UnixMilli() {
+		t.Errorf("checklist due = %d", todos[1].DueTime)
+	}
+
+	result, err = bridge.Sync(ctx, "union1", now.Add(-2*time.Hour), now.Add(24*time.Hour))
+	if err != nil || len(result.Created) != 0 || len(result.Existing) != 2 {
+		t.Fatalf("second sync: result=%+v, err=%v", result, err)
+	}
+	if len(s.Store.Todos("union1")) != 2 {
+		t.Errorf("todos duplicated")
+	}
+}
@@ -0,0 +1,108 @@
+package dingtalk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	url2 "net/url"
+	"strings"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+// GetProcessSchema 获取审批模板的表单 schema
+func (c *Client) GetProcessSchema(ctx context.Context, processCode string) (*models.ProcessSchema, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/obtain-the-form-schema
+	query := url2.Values{}
+	query.Set("processCode", processCode)
+	resp := &models.ProcessSchemaResponse{}
+	if err := c.doApi(ctx, http.MethodGet, "/v1.0/workflow/forms/schemas/processCodes", query, nil, resp); err != nil {
+		return nil, err
+	}
+	return &resp.Result, nil
+}
+
+// CreateProcessInstance 发起审批实例，返回实例 ID
+func (c *Client) CreateProcessInstance(ctx context.Context, req *models.CreateProcessInstanceRequest) (string, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/create-an-approval-instance
+	if req.ProcessCode == "" || req.OriginatorUserID == "" {
+		return "", fmt.Errorf("dingtalk.CreateProcessInstance, processCode and originatorUserId are required")
+	}
+	resp := &models.CreateProcessInstanceResponse{}
+	if err := c.doApi(ctx, http.MethodPost, "/v1.0/workflow/processInstances", nil, req, resp); err != nil {
+		return "", err
+	}
+	return resp.InstanceID, nil
+}
+
+// GetProcessInstance 获取审批实例详情
+func (c *Client) GetProcessInstance(ctx context.Context, processInstanceId string) (*models.ProcessInstance, error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/obtains-the-details-of-a-single-approval-instance-pop
+	query := url2.Values{}
+	query.Set("processInstanceId", processInstanceId)
+	resp := &models.ProcessInstanceResponse{}
+	if err := c.doApi(ctx, http.MethodGet, "/v1.0/workflow/processInstances", query, nil, resp); err != nil {
+		return nil, err
+	}
+	return &resp.Result, nil
+}
+
+// ListProcessInstanceIDs 分页获取审批实例 ID，时间范围不能超过 120 天，cursor 首次传 0，
+// 返回的 nextCursor 为 0 表示没有更多数据；userIds 为空时查询所有发起人
+func (c *Client) ListProcessInstanceIDs(ctx context.Context, processCode string, start, end time.Time,
+	userIds []string, cursor int64, size int) (ids []string, nextCursor int64, err error) {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/obtain-an-approval-list-of-instance-ids
+	if size <= 0 || size > 20 {
+		size = 20
+	}
+	req := models.TopListProcessInstanceIDsRequest{
+		ProcessCode: processCode,
+		StartTime:   start.UnixMilli(),
+		Size:        size,
+		Cursor:      cursor,
+		UserIDList:  strings.Join(userIds, ","),
+	}
+	if !end.IsZero() {
+		req.EndTime = end.UnixMilli()
+	}
+	resp := &models.TopListProcessInstanceIDsResponse{}
+	if err = c.doTopApi(ctx, "/topapi/processinstance/listids", req, resp); err != nil {
+		return nil, 0, err
+	}
+	return resp.List, resp.NextCursor, nil
+}
+
+// IterateProcessInstanceIDs 遍历 [start, end] 内发起的审批实例 ID，参数同 ListProcessInstanceIDs
+func (c *Client) IterateProcessInstanceIDs(processCode string, start, end time.Time, userIds []string) *Pager[string] {
+	return NewPager(CursorPages(func(ctx context.Context, cursor int64) ([]string, int64, bool, error) {
+		ids, next, err := c.ListProcessInstanceIDs(ctx, processCode, start, end, userIds, cursor, 20)
+		return ids, next, next != 0, err
+	}))
+}
+
+// AddProcessComment 为审批实例添加评论
+func (c *Client) AddProcessComment(ctx context.Context, req *models.AddProcessCommentRequest) error {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/add-an-approval-comment-pop
+	resp := &models.ProcessBoolResponse{}
+	if err := c.doApi(ctx, http.MethodPost, "/v1.0/workflow/processInstances/comments", nil, req, resp); err != nil {
+		return err
+	}
+	if !resp.Result {
+		return fmt.Errorf("dingtalk.AddProcessComment, add comment to %s failed", req.ProcessInstanceID)
+	}
+	return nil
+}
+
+// TerminateProcessInstance 撤销审批实例
+func (c *Client) TerminateProcessInstance(ctx context.Context, req *models.TerminateProcessInstanceRequest) error {
+	// OpenAPI doc: https://open.dingtalk.com/document/orgapp/revoke-an-approval-instance
+	resp := &models.ProcessBoolResponse{}
+	if err := c.doApi(ctx, http.MethodPost, "/v1.0/workflow/processInstances/terminate", nil, req, resp); err != nil {
+		return err
+	}
+	if !resp.Result {
+		return fmt.Errorf("dingtalk.TerminateProcessInstance, terminate %s failed", req.ProcessInstanceID)
+	}
+	return nil
+}
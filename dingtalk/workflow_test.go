@@ -0,0 +1,131 @@
+package dingtalk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chzealot/gobase/dingtalk/models"
+)
+
+func TestProcessInstance(t *testing.T) {
+	var created models.CreateProcessInstanceRequest
+	var terminated models.TerminateProcessInstanceRequest
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/v1.0/workflow/processInstances":
+			if r.Header.Get("x-acs-dingtalk-access-token") != "app-token" {
+				t.Errorf("access token header = %q", r.Header.Get("x-acs-dingtalk-access-token"))
+			}
+			if r.Method == http.MethodPost {
+				_ = json.NewDecoder(r.Body).Decode(&created)
+				_, _ = w.Write([]byte(`{"instanceId":"inst-1"}`))
+				return
+			}
+			if r.URL.Query().Get("processInstanceId") != "inst-1" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"code":"InvalidParameter","message":"instance not found"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"success":true,"result":{"title":"报销","status":"COMPLETED","result":"agree",
+				"createTime":"2024-03-01T10:00Z","tasks":[{"taskId":1,"userId":"u2","status":"COMPLETED","result":"AGREE"}]}}`))
+		case "/v1.0/workflow/processInstances/terminate":
+			_ = json.NewDecoder(r.Body).Decode(&terminated)
+			_, _ = w.Write([]byte(`{"success":true,"result":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	})
+	ctx := context.Background()
+
+	if _, err := client.CreateProcessInstance(ctx, &models.CreateProcessInstanceRequest{ProcessCode: "PROC-1"}); err == nil {
+		t.Error("CreateProcessInstance() without originator error = nil")
+	}
+	id, err := client.CreateProcessInstance(ctx, &models.CreateProcessInstanceRequest{
+		ProcessCode:         "PROC-1",
+		OriginatorUserID:    "u1",
+		FormComponentValues: []models.FormComponentValue{models.TextFormValue("事由", "出差")},
+	})
+	if err != nil || id != "inst-1" {
+		t.Fatalf("CreateProcessInstance() = %q, %v", id, err)
+	}
+	if created.OriginatorUserID != "u1" || len(created.FormComponentValues) != 1 || created.FormComponentValues[0].Value != "出差" {
+		t.Errorf("create request = %+v", created)
+	}
+
+	instance, err := client.GetProcessInstance(ctx, "inst-1")
+	if err != nil {
+		t.Fatalf("GetProcessInstance() error = %v", err)
+	}
+	if instance.Status != models.ProcessStatusCompleted || !instance.Status.IsFinished() || len(instance.Tasks) != 1 ||
+		instance.CreateTime.IsZero() {
+		t.Errorf("GetProcessInstance() = %+v", instance)
+	}
+	if _, err := client.GetProcessInstance(ctx, "missing"); ErrorKindOf(err) != ErrorKindInvalidParameter {
+		t.Errorf("GetProcessInstance(missing) error = %v", err)
+	}
+
+	err = client.TerminateProcessInstance(ctx, &models.TerminateProcessInstanceRequest{
+		ProcessInstanceID: "inst-1", OperatingUserID: "u1", Remark: "重复提交"})
+	if err != nil || terminated.ProcessInstanceID != "inst-1" || terminated.OperatingUserID != "u1" {
+		t.Errorf("TerminateProcessInstance() = %v, request = %+v", err, terminated)
+	}
+}
+
+func TestTerminateProcessInstanceFailed(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		default:
+			_, _ = w.Write([]byte(`{"success":true,"result":false}`))
+		}
+	})
+	err := client.TerminateProcessInstance(context.Background(), &models.TerminateProcessInstanceRequest{ProcessInstanceID: "inst-1"})
+	if err == nil {
+		t.Error("TerminateProcessInstance() with result=false error = nil")
+	}
+}
+
+func TestIterateProcessInstanceIDs(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	var cursors []int64
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gettoken":
+			_, _ = w.Write([]byte(`{"errcode":0,"access_token":"app-token","expires_in":7200}`))
+		case "/topapi/processinstance/listids":
+			req := &models.TopListProcessInstanceIDsRequest{}
+			_ = json.NewDecoder(r.Body).Decode(req)
+			if req.ProcessCode != "PROC-1" || req.StartTime != start.UnixMilli() || req.EndTime != 0 ||
+				req.Size != 20 || req.UserIDList != "u1,u2" {
+				t.Errorf("listids request = %+v", req)
+			}
+			cursors = append(cursors, req.Cursor)
+			switch req.Cursor {
+			case 0:
+				_, _ = w.Write([]byte(`{"errcode":0,"result":{"list":["a","b"],"next_cursor":20}}`))
+			case 20:
+				_, _ = w.Write([]byte(`{"errcode":0,"result":{"list":["c"]}}`))
+			default:
+				_, _ = w.Write([]byte(`{"errcode":400002,"errmsg":"invalid cursor"}`))
+			}
+		}
+	})
+	ids, err := client.IterateProcessInstanceIDs("PROC-1", start, time.Time{}, []string{"u1", "u2"}).All(context.Background())
+	if err != nil {
+		t.Fatalf("IterateProcessInstanceIDs() error = %v", err)
+	}
+	if len(ids) != 3 || ids[2] != "c" || len(cursors) != 2 || cursors[1] != 20 {
+		t.Errorf("ids = %v, cursors = %v", ids, cursors)
+	}
+
+	if _, _, err := client.ListProcessInstanceIDs(context.Background(), "PROC-1", start, time.Time{},
+		[]string{"u1", "u2"}, 40, 100); err == nil {
+		t.Error("ListProcessInstanceIDs() with errcode error = nil")
+	}
+}
@@ -2,6 +2,7 @@ module github.com/chzealot/gobase
 
 require (
 	github.com/duke-git/lancet/v2 v2.2.0
+	github.com/gorilla/websocket v1.5.0
 	github.com/lestrrat-go/file-rotatelogs v2.4.0+incompatible
 	github.com/pkg/errors v0.9.1
 	go.uber.org/zap v1.24.0